@@ -0,0 +1,446 @@
+// Package fingerprint identifies when two recordings (or two runs of the same rider) cover the
+// same track section, by matching the characteristic suspension velocity response rather than
+// GPS. It adapts the constant-Q spectral-peak landmark fingerprinting scheme used by audio
+// identification systems: sparse, robust peaks are picked out of a time-frequency
+// representation of the velocity signal, hashed as (anchor, target) landmark pairs, and indexed
+// so a query recording can be matched against a library by landmark-hash collisions.
+package fingerprint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+
+	"github.com/manhatma/sst/gosst/formats/psst"
+)
+
+const (
+	// MinFreqHz / MaxFreqHz bound the log-frequency bands the spectrogram is built over;
+	// suspension dynamics live in roughly 0-10 Hz (see the WH_LAMBDA comments in psst.go), so a
+	// wider 0.5-30 Hz range is used to also capture slower compressions and sharp hits.
+	MinFreqHz = 0.5
+	MaxFreqHz = 30.0
+	// BandsPerOctave controls the frequency resolution of the log-spaced bands.
+	BandsPerOctave = 16
+	// HopSeconds is the time between successive spectrogram frames.
+	HopSeconds = 0.025
+	// timeMaxFilterFrames / freqMaxFilterBins size the 2D max filter a peak must dominate in
+	// both axes to be kept, matching the sparsity of a typical audio landmark fingerprinter.
+	timeMaxFilterFrames = 25
+	freqMaxFilterBins   = 10
+	// targetMinDt / targetMaxDt / targetMaxDf bound the target zone each anchor peak pairs
+	// against when forming landmarks.
+	targetMinDt = HopSeconds
+	targetMaxDt = 2.0
+	targetMaxDf = 6.0 // octaves
+	// targetFanout caps how many target-zone peaks an anchor pairs with.
+	targetFanout = 3
+	// matchThreshold is the minimum number of aligned landmark hits for a candidate recording to
+	// be reported by Match.
+	matchThreshold = 8
+)
+
+var candidateScales = []float64{0.8, 0.9, 1.0, 1.1, 1.2}
+
+// peak is a local maximum of the spectrogram, identified by its frame/band indices and
+// time/log-frequency coordinates.
+type peak struct {
+	frame int
+	band  int
+	time  float64
+	logF  float64
+	mag   float64
+}
+
+// landmark pairs an anchor peak with one of its nearby target-zone peaks, the unit a hash is
+// derived from.
+type landmark struct {
+	anchorTime float64
+	deltaTime  float64
+	deltaLogF  float64
+	anchorBand int
+}
+
+// hashKey quantizes a landmark into a lookup key; quantization buckets are chosen coarse enough
+// to tolerate the jitter WH smoothing and sample-rate differences introduce between otherwise
+// matching recordings.
+type hashKey struct {
+	anchorBand   int
+	deltaTimeBin int
+	deltaFreqBin int
+}
+
+type indexEntry struct {
+	recordingID string
+	anchorTime  float64
+}
+
+// MatchResult is one candidate recording aligned against a query, with the time alignment that
+// produced the most landmark-hash hits.
+type MatchResult struct {
+	RecordingID string
+	Hits        int
+	TimeOffset  float64 // query time * TimeScale + TimeOffset = matched recording time
+	TimeScale   float64 // in [0.8, 1.2]
+}
+
+// FingerprintDB is an in-memory index of landmark hashes to the recordings/anchor-times they
+// occurred in, keyed by Processed.Name so a library of recordings can be indexed incrementally.
+type FingerprintDB struct {
+	index map[hashKey][]indexEntry
+	names map[string]bool
+}
+
+// NewFingerprintDB creates an empty fingerprint index.
+func NewFingerprintDB() *FingerprintDB {
+	return &FingerprintDB{index: make(map[hashKey][]indexEntry)}
+}
+
+// Add computes and indexes the fingerprint of pd under pd.Name. Re-adding the same name replaces
+// nothing (landmarks simply accumulate); callers should avoid indexing the same recording twice.
+func (db *FingerprintDB) Add(pd *psst.Processed) error {
+	landmarks, err := landmarksOf(pd)
+	if err != nil {
+		return fmt.Errorf("fingerprint: %s: %w", pd.Name, err)
+	}
+	if db.names == nil {
+		db.names = make(map[string]bool)
+	}
+	db.names[pd.Name] = true
+	for _, lm := range landmarks {
+		key := hashLandmark(lm)
+		db.index[key] = append(db.index[key], indexEntry{recordingID: pd.Name, anchorTime: lm.anchorTime})
+	}
+	return nil
+}
+
+// Match finds recordings in db whose suspension response pattern aligns with query's, returning
+// candidates with at least matchThreshold aligned landmark hits, best match first.
+func (db *FingerprintDB) Match(query *psst.Processed) ([]MatchResult, error) {
+	landmarks, err := landmarksOf(query)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint: %s: %w", query.Name, err)
+	}
+
+	// votes[scale][recordingID][offsetBin] = hit count; offset is quantized to the same time
+	// resolution as HopSeconds so near-identical alignments accumulate into the same bin.
+	type voteKey struct {
+		recordingID string
+		offsetBin   int
+	}
+	votesByScale := make([]map[voteKey]int, len(candidateScales))
+	for i := range votesByScale {
+		votesByScale[i] = make(map[voteKey]int)
+	}
+
+	for _, lm := range landmarks {
+		for scaleIdx, scale := range candidateScales {
+			// The db was indexed at scale 1.0 (hashLandmark), so a query landmark whose
+			// deltaTime has been stretched/compressed by scale must have that same scale
+			// un-done before it can collide with the right hashKey bucket - rescaling only
+			// scaledAnchor below, as before, would line up the votes' time axis but leaves the
+			// lookup itself scale-blind, so a truly time-scaled recording would never produce
+			// any candidate entries to vote on in the first place.
+			key := hashLandmarkScaled(lm, scale)
+			entries := db.index[key]
+			if len(entries) == 0 {
+				continue
+			}
+			scaledAnchor := lm.anchorTime * scale
+			for _, e := range entries {
+				offset := e.anchorTime - scaledAnchor
+				bin := int(math.Round(offset / HopSeconds))
+				votesByScale[scaleIdx][voteKey{e.recordingID, bin}]++
+			}
+		}
+	}
+
+	best := make(map[string]MatchResult)
+	for scaleIdx, scale := range candidateScales {
+		for vk, hits := range votesByScale[scaleIdx] {
+			if hits < matchThreshold {
+				continue
+			}
+			if existing, ok := best[vk.recordingID]; !ok || hits > existing.Hits {
+				best[vk.recordingID] = MatchResult{
+					RecordingID: vk.recordingID,
+					Hits:        hits,
+					TimeOffset:  float64(vk.offsetBin) * HopSeconds,
+					TimeScale:   scale,
+				}
+			}
+		}
+	}
+
+	results := make([]MatchResult, 0, len(best))
+	for _, r := range best {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Hits > results[j].Hits })
+	return results, nil
+}
+
+// landmarksOf computes the combined front+rear velocity signal's spectrogram peaks and pairs
+// them into landmarks.
+func landmarksOf(pd *psst.Processed) ([]landmark, error) {
+	velocity := combinedVelocity(pd)
+	if len(velocity) == 0 {
+		return nil, fmt.Errorf("no velocity data to fingerprint")
+	}
+	if pd.Meta.SampleRate == 0 {
+		return nil, fmt.Errorf("sample rate is zero")
+	}
+
+	peaks, err := spectrogramPeaks(velocity, float64(pd.Meta.SampleRate))
+	if err != nil {
+		return nil, err
+	}
+	return buildLandmarks(peaks), nil
+}
+
+// combinedVelocity sums the signed front and rear velocities sample-for-sample (zero where a
+// side is absent or recordings differ in length), since a track section's suspension response
+// shows up on whichever wheel(s) hit it.
+func combinedVelocity(pd *psst.Processed) []float64 {
+	n := len(pd.Front.Velocity)
+	if len(pd.Rear.Velocity) > n {
+		n = len(pd.Rear.Velocity)
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if i < len(pd.Front.Velocity) {
+			out[i] += pd.Front.Velocity[i]
+		}
+		if i < len(pd.Rear.Velocity) {
+			out[i] += pd.Rear.Velocity[i]
+		}
+	}
+	return out
+}
+
+// logBands returns the center frequencies (Hz) of the log-spaced analysis bands.
+func logBands() []float64 {
+	octaves := math.Log2(MaxFreqHz / MinFreqHz)
+	n := int(octaves*BandsPerOctave) + 1
+	bands := make([]float64, n)
+	for i := range bands {
+		bands[i] = MinFreqHz * math.Pow(2, float64(i)/BandsPerOctave)
+	}
+	return bands
+}
+
+// baseWindowHops is the FFT window length, in hops, used for the octave directly below
+// MaxFreqHz. Each octave further down from MaxFreqHz doubles the window (see octaveGroup/
+// spectrogramPeaks), halving the frequency resolution needed to keep the same number of bins
+// per octave as the one above it - a constant-Q spectrogram, rather than one short window shared
+// across the whole 0.5-30 Hz range (which left only a couple of bins resolvable below 10 Hz,
+// where suspension dynamics actually live).
+const baseWindowHops = 8
+
+// octaveGroup buckets a frequency into how many octaves below MaxFreqHz it falls (0 = the
+// topmost octave, using the shortest window; increasing going down). Bands sharing a group share
+// one FFT window length.
+func octaveGroup(f float64) int {
+	g := int(math.Floor(math.Log2(MaxFreqHz / f)))
+	if g < 0 {
+		g = 0
+	}
+	return g
+}
+
+// spectrogramPeaks computes a log-frequency magnitude spectrogram of signal (one FFT per hop per
+// octave group, each group's window doubling in length the lower its octave - see octaveGroup -
+// so the per-band bin picked below actually has the frequency resolution the log spacing implies
+// instead of several adjacent bands collapsing onto the same one or two bins), then keeps only
+// the local maxima that dominate both a timeMaxFilterFrames-wide time window and a
+// freqMaxFilterBins-wide frequency window, per the landmark-fingerprinting scheme.
+func spectrogramPeaks(signal []float64, sampleRate float64) ([]peak, error) {
+	bands := logBands()
+	hop := int(HopSeconds * sampleRate)
+	if hop < 1 {
+		hop = 1
+	}
+
+	numGroups := octaveGroup(MinFreqHz) + 1
+	windows := make([]int, numGroups)
+	ffts := make([]*fourier.FFT, numGroups)
+	for g := range windows {
+		w := hop * baseWindowHops << g
+		if w > len(signal) {
+			w = len(signal)
+		}
+		windows[g] = w
+		if w >= 2 {
+			ffts[g] = fourier.NewFFT(w)
+		}
+	}
+	if windows[0] < 2 {
+		return nil, fmt.Errorf("signal too short to fingerprint")
+	}
+
+	nFrames := (len(signal)-windows[0])/hop + 1
+	if nFrames < 1 {
+		nFrames = 1
+	}
+
+	bufs := make([][]float64, numGroups)
+	for g, w := range windows {
+		if w >= 2 {
+			bufs[g] = make([]float64, w)
+		}
+	}
+
+	mag := make([][]float64, nFrames) // mag[frame][band]
+	coeffsByGroup := make([][]complex128, numGroups)
+	for frame := 0; frame < nFrames; frame++ {
+		start := frame * hop
+		for g, w := range windows {
+			if w < 2 {
+				continue
+			}
+			buf := bufs[g]
+			end := start + w
+			if end > len(signal) {
+				end = len(signal)
+			}
+			n := end - start
+			copy(buf, signal[start:end])
+			for i := n; i < w; i++ {
+				buf[i] = 0
+			}
+			coeffsByGroup[g] = ffts[g].Coefficients(nil, buf)
+		}
+
+		row := make([]float64, len(bands))
+		for bi, f := range bands {
+			g := octaveGroup(f)
+			for windows[g] < 2 && g > 0 {
+				g--
+			}
+			coeffs := coeffsByGroup[g]
+			freqBinHz := sampleRate / float64(windows[g])
+			bin := int(math.Round(f / freqBinHz))
+			if bin < 1 {
+				bin = 1
+			}
+			if bin >= len(coeffs) {
+				bin = len(coeffs) - 1
+			}
+			row[bi] = math.Hypot(real(coeffs[bin]), imag(coeffs[bin]))
+		}
+		mag[frame] = row
+	}
+
+	var peaks []peak
+	for frame := 0; frame < nFrames; frame++ {
+		for band := range bands {
+			v := mag[frame][band]
+			if v <= 0 || !isLocalMax2D(mag, frame, band, timeMaxFilterFrames, freqMaxFilterBins) {
+				continue
+			}
+			peaks = append(peaks, peak{
+				frame: frame,
+				band:  band,
+				time:  float64(frame) * HopSeconds,
+				logF:  math.Log2(bands[band] / MinFreqHz),
+				mag:   v,
+			})
+		}
+	}
+	return peaks, nil
+}
+
+// isLocalMax2D reports whether mag[frame][band] is >= every other value within timeSpan/2
+// frames and freqSpan/2 bands of it on either side (clipped at the spectrogram edges).
+func isLocalMax2D(mag [][]float64, frame, band, timeSpan, freqSpan int) bool {
+	v := mag[frame][band]
+	tLo, tHi := frame-timeSpan/2, frame+timeSpan/2
+	fLo, fHi := band-freqSpan/2, band+freqSpan/2
+	for t := tLo; t <= tHi; t++ {
+		if t < 0 || t >= len(mag) {
+			continue
+		}
+		for f := fLo; f <= fHi; f++ {
+			if f < 0 || f >= len(mag[t]) {
+				continue
+			}
+			if t == frame && f == band {
+				continue
+			}
+			if mag[t][f] > v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// buildLandmarks pairs each anchor peak with up to targetFanout peaks in its target zone
+// (bounded by targetMinDt/targetMaxDt/targetMaxDf), the nearest ones first.
+func buildLandmarks(peaks []peak) []landmark {
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].time < peaks[j].time })
+
+	var landmarks []landmark
+	for i, anchor := range peaks {
+		type candidate struct {
+			dt float64
+			p  peak
+		}
+		var candidates []candidate
+		for j := i + 1; j < len(peaks); j++ {
+			target := peaks[j]
+			dt := target.time - anchor.time
+			if dt < targetMinDt {
+				continue
+			}
+			if dt > targetMaxDt {
+				break // peaks are time-sorted, nothing further can be in range
+			}
+			if math.Abs(target.logF-anchor.logF) > targetMaxDf {
+				continue
+			}
+			candidates = append(candidates, candidate{dt, target})
+		}
+		sort.Slice(candidates, func(a, b int) bool { return candidates[a].dt < candidates[b].dt })
+		if len(candidates) > targetFanout {
+			candidates = candidates[:targetFanout]
+		}
+		for _, c := range candidates {
+			landmarks = append(landmarks, landmark{
+				anchorTime: anchor.time,
+				deltaTime:  c.dt,
+				deltaLogF:  c.p.logF - anchor.logF,
+				anchorBand: anchor.band,
+			})
+		}
+	}
+	return landmarks
+}
+
+// hashLandmark quantizes a landmark into a lookup key, at scale 1.0 (see hashLandmarkScaled);
+// this is what db.Add indexes recordings under.
+func hashLandmark(lm landmark) hashKey {
+	return hashLandmarkScaled(lm, 1.0)
+}
+
+// hashLandmarkScaled is hashLandmark with deltaTime rescaled by scale before quantizing.
+// Delta-time is binned at HopSeconds resolution and delta-frequency at a twelfth of an octave,
+// coarse enough to survive the jitter WH smoothing introduces without collapsing distinct
+// patterns together. Match sweeps scale over candidateScales when looking a query landmark up
+// against a db indexed at scale 1.0, since a recording covering the same track section at a
+// different speed stretches deltaTime (between two landmark peaks) by the same factor it
+// stretches anchorTime by - rescaling only anchorTime for vote alignment, as Match used to, left
+// the lookup itself scale-blind and a stretched recording's landmarks simply never collided with
+// the right bucket.
+func hashLandmarkScaled(lm landmark, scale float64) hashKey {
+	return hashKey{
+		anchorBand:   lm.anchorBand,
+		deltaTimeBin: int(math.Round(lm.deltaTime * scale / HopSeconds)),
+		deltaFreqBin: int(math.Round(lm.deltaLogF * 12)),
+	}
+}
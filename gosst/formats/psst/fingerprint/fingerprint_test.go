@@ -0,0 +1,135 @@
+package fingerprint
+
+import (
+	"math"
+	"testing"
+
+	"github.com/manhatma/sst/gosst/formats/psst"
+)
+
+// TestSpectrogramPeaksResolvesTrueFrequency checks that spectrogramPeaks' per-octave FFT windows
+// (see octaveGroup) actually resolve a tone's frequency, rather than collapsing the whole 0.5-30
+// Hz range onto the handful of bins a single short window would produce. For each test tone, at
+// least one detected peak's band frequency must land close to the tone's actual frequency.
+func TestSpectrogramPeaksResolvesTrueFrequency(t *testing.T) {
+	const sampleRate = 200.0
+	n := int(10.0 * sampleRate)
+	bands := logBands()
+
+	for _, f := range []float64{1.0, 2.0, 4.0, 8.0, 20.0} {
+		sig := make([]float64, n)
+		for i := range sig {
+			sig[i] = math.Sin(2 * math.Pi * f * float64(i) / sampleRate)
+		}
+
+		peaks, err := spectrogramPeaks(sig, sampleRate)
+		if err != nil {
+			t.Fatalf("f=%.1f: spectrogramPeaks failed: %v", f, err)
+		}
+
+		found := false
+		for _, p := range peaks {
+			if math.Abs(math.Log2(bands[p.band]/f)) < 0.6 { // within ~1.5x
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("f=%.1f Hz: no peak landed near the true frequency among %d peaks", f, len(peaks))
+		}
+	}
+}
+
+// TestHashLandmarkScaledRecoversUnscaledKey verifies that a landmark whose anchorTime/deltaTime
+// have been uniformly stretched by 1/scale hashes, under hashLandmarkScaled(lm, scale), to the
+// same key the original, unstretched landmark hashes to under hashLandmark (scale 1.0) - the
+// property Match's candidateScales sweep relies on to find a time-scaled recording's landmarks in
+// a db indexed at scale 1.0, rather than only rescaling the reported vote offset.
+func TestHashLandmarkScaledRecoversUnscaledKey(t *testing.T) {
+	lm := landmark{anchorTime: 12.0, deltaTime: 0.8, deltaLogF: 2.3, anchorBand: 40}
+	want := hashLandmark(lm)
+
+	for _, scale := range candidateScales {
+		stretched := landmark{
+			anchorTime: lm.anchorTime / scale,
+			deltaTime:  lm.deltaTime / scale,
+			deltaLogF:  lm.deltaLogF,
+			anchorBand: lm.anchorBand,
+		}
+		if got := hashLandmarkScaled(stretched, scale); got != want {
+			t.Errorf("scale=%.1f: hashLandmarkScaled(stretched, scale) = %+v, want %+v", scale, got, want)
+		}
+	}
+}
+
+// resampleScaled linearly resamples velDB as if it had been captured scale times faster, so
+// query_time*scale == db_time for corresponding samples (the relationship Match's scale sweep
+// assumes, see hashLandmarkScaled).
+func resampleScaled(velDB []float64, scale float64) []float64 {
+	out := make([]float64, int(float64(len(velDB))/scale))
+	for i := range out {
+		srcIdx := float64(i) * scale
+		i0 := int(math.Floor(srcIdx))
+		if i0+1 >= len(velDB) {
+			break
+		}
+		frac := srcIdx - float64(i0)
+		out[i] = velDB[i0]*(1-frac) + velDB[i0+1]*frac
+	}
+	return out
+}
+
+// TestMatchFindsTimeScaledRecording builds a db recording out of several time-localized
+// frequency bursts, then matches a resampled (time-scaled) copy of it against the db: before
+// hashLandmarkScaled, Match's lookup hashed every query landmark at scale 1.0 regardless of which
+// candidateScales entry it was voting under, so a genuinely time-scaled recording's landmarks
+// never collided with anything in the db and Match always returned no results for it.
+func TestMatchFindsTimeScaledRecording(t *testing.T) {
+	const sampleRate = 200.0
+	const recordingID = "reference"
+
+	freqs := []float64{3, 5, 2, 7, 4, 6, 2.5}
+	velDB := make([]float64, int(6.0*sampleRate))
+	for i := range velDB {
+		t := float64(i) / sampleRate
+		for j := 0; j < 9; j++ {
+			eventTime := 0.5 + float64(j)*0.5
+			freq := freqs[j%len(freqs)]
+			dt := t - eventTime
+			window := math.Exp(-(dt * dt) / (2 * 0.12 * 0.12))
+			velDB[i] += 40 * window * math.Sin(2*math.Pi*freq*t)
+		}
+	}
+
+	pdDB := &psst.Processed{}
+	pdDB.Name = recordingID
+	pdDB.SampleRate = sampleRate
+	pdDB.Front.Present = true
+	pdDB.Front.Velocity = velDB
+
+	db := NewFingerprintDB()
+	if err := db.Add(pdDB); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	const scale = 1.2
+	pdQuery := &psst.Processed{}
+	pdQuery.Name = "query"
+	pdQuery.SampleRate = sampleRate
+	pdQuery.Front.Present = true
+	pdQuery.Front.Velocity = resampleScaled(velDB, scale)
+
+	results, err := db.Match(pdQuery)
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Match found no candidates for a time-scaled copy of an indexed recording")
+	}
+	if results[0].RecordingID != recordingID {
+		t.Errorf("best match RecordingID = %q, want %q", results[0].RecordingID, recordingID)
+	}
+	if results[0].Hits < matchThreshold {
+		t.Errorf("best match Hits = %d, want >= matchThreshold (%d)", results[0].Hits, matchThreshold)
+	}
+}
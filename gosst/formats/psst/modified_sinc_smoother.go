@@ -6,13 +6,35 @@ import (
 	"math"
 )
 
+// MSBoundaryMode selects how ModifiedSincSmoother.Smooth treats samples
+// that fall outside the input range while the kernel slides past the edges.
+type MSBoundaryMode int
+
+const (
+	// BoundaryZero implicitly zero-pads the input (the original behaviour).
+	BoundaryZero MSBoundaryMode = iota
+	// BoundaryLinearExtrap fits a line to the first/last samples and
+	// extends the input along it, per Schmid et al.[cite: 181].
+	BoundaryLinearExtrap
+	// BoundaryReflect mirrors the input around each edge.
+	BoundaryReflect
+)
+
 // ModifiedSincSmoother holds the precomputed kernel for MS smoothing.
 type ModifiedSincSmoother struct {
-	kernel []float64
-	mValue int // half-width 'm' of the kernel
-	nValue int // degree 'n' of the MS kernel
+	kernel   []float64
+	mValue   int // half-width 'm' of the kernel
+	nValue   int // degree 'n' of the MS kernel
+	boundary MSBoundaryMode
 }
 
+// msSupportedOrders lists the n_ms degrees the MS kernel (Eq. 3-6 [cite: 82,89]) supports.
+// No Eq. 7 correction polynomial is applied: for n_ms 6, 8 and 10 the base sinc*window kernel
+// already meets the ≤1% passband spec up to f_c = 1/(m_ms+1) (see
+// TestModifiedSincFrequencyResponse), and the correction previously tabulated for them made the
+// passband worse rather than better.
+var msSupportedOrders = map[int]bool{2: true, 4: true, 6: true, 8: true, 10: true}
+
 // sinc calculates the sinc function sin(pi*x)/(pi*x).
 func sinc(x float64) float64 {
 	if x == 0.0 {
@@ -23,11 +45,15 @@ func sinc(x float64) float64 {
 }
 
 // NewModifiedSincSmoother creates a new smoother instance for MS smoothing.
-// n_ms: degree parameter for MS kernel (e.g., 2, 4, 6, 8 from the paper [cite: 83, 97]).
+// n_ms: degree parameter for MS kernel, one of 2, 4, 6, 8, 10 [cite: 83, 97].
 // m_ms: half-width of the MS kernel. Controls smoothness; larger m = more smoothing.
 //       Minimum m_ms is typically n_ms/2 + 2[cite: 261].
 // alpha_ms: Gaussian width parameter for the window function (e.g., 4.0 [cite: 88]).
 func NewModifiedSincSmoother(n_ms, m_ms int, alpha_ms float64) (*ModifiedSincSmoother, error) {
+	if !msSupportedOrders[n_ms] {
+		return nil, fmt.Errorf("MS Smoother: unsupported n_ms (%d), must be one of 2, 4, 6, 8, 10", n_ms)
+	}
+
 	min_m_required := n_ms/2 + 2
 	if m_ms < min_m_required {
 		return nil, fmt.Errorf("MS Smoother: m_ms (%d) is too small for n_ms (%d). Must be >= %d", m_ms, n_ms, min_m_required)
@@ -37,7 +63,6 @@ func NewModifiedSincSmoother(n_ms, m_ms int, alpha_ms float64) (*ModifiedSincSmo
 	kernel := make([]float64, kernelSize)
 
 	// Calculate kernel values based on Equations 3, 4, 5 from Schmid et al., 2022 [cite: 82]
-	// For n_ms <= 4, no correction terms (eq. 7) are needed[cite: 105].
 	// The kernel is a(i) = A * w_alpha(x) * sinc_term(x)
 	// x = i_kernel / (m_ms + 1), where i_kernel ranges from -m_ms to m_ms.
 
@@ -58,7 +83,6 @@ func NewModifiedSincSmoother(n_ms, m_ms int, alpha_ms float64) (*ModifiedSincSmo
 		sincArg := (float64(n_ms) + 4.0) / 2.0 * x
 		sincVal := sinc(sincArg)
 
-		// No correction terms for n_ms=2 [cite: 105]
 		kernel[k_idx] = w_alpha_x * sincVal
 		sumKernel += kernel[k_idx]
 	}
@@ -72,7 +96,54 @@ func NewModifiedSincSmoother(n_ms, m_ms int, alpha_ms float64) (*ModifiedSincSmo
 		kernel[k_idx] /= sumKernel
 	}
 
-	return &ModifiedSincSmoother{kernel: kernel, mValue: m_ms, nValue: n_ms}, nil
+	return &ModifiedSincSmoother{kernel: kernel, mValue: m_ms, nValue: n_ms, boundary: BoundaryZero}, nil
+}
+
+// NewModifiedSincSmootherWithBoundary creates a new MS smoother with an explicit
+// boundary handling mode (see MSBoundaryMode), otherwise identical to
+// NewModifiedSincSmoother.
+func NewModifiedSincSmootherWithBoundary(n_ms, m_ms int, alpha_ms float64, boundary MSBoundaryMode) (*ModifiedSincSmoother, error) {
+	ms, err := NewModifiedSincSmoother(n_ms, m_ms, alpha_ms)
+	if err != nil {
+		return nil, err
+	}
+	ms.boundary = boundary
+	return ms, nil
+}
+
+// SetBoundaryMode changes the boundary handling used by subsequent calls to Smooth.
+func (ms *ModifiedSincSmoother) SetBoundaryMode(boundary MSBoundaryMode) {
+	ms.boundary = boundary
+}
+
+// fitLine computes the least-squares slope/intercept of data[start:start+n]
+// against their actual indices, i.e. the line y = a + b*x minimizing the
+// squared error over x in [start, start+n).
+func fitLine(data []float64, start, n int) (a, b float64) {
+	if n <= 1 {
+		if n == 1 {
+			return data[start], 0
+		}
+		return 0, 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i := 0; i < n; i++ {
+		x := float64(start + i)
+		y := data[start+i]
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	nf := float64(n)
+	denom := nf*sumXX - sumX*sumX
+	if denom == 0 {
+		return sumY / nf, 0
+	}
+	b = (nf*sumXY - sumX*sumY) / denom
+	a = (sumY - b*sumX) / nf
+	return a, b
 }
 
 // Smooth applies MS smoothing (convolution) to the data.
@@ -92,6 +163,19 @@ func (ms *ModifiedSincSmoother) Smooth(data []float64) ([]float64, error) {
 	kernelLen := len(ms.kernel)
 	// m is the kernel half-width, ms.mValue should be (kernelLen - 1) / 2
 
+	// For BoundaryLinearExtrap, fit a line to the first/last min(2*m+1, len(data))
+	// samples once per call and use it to virtually extend the input by m samples
+	// on each side (Schmid et al.[cite: 181]).
+	var aLeft, bLeft, aRight, bRight float64
+	if ms.boundary == BoundaryLinearExtrap {
+		fitLen := 2*ms.mValue + 1
+		if fitLen > dataLen {
+			fitLen = dataLen
+		}
+		aLeft, bLeft = fitLine(data, 0, fitLen)
+		aRight, bRight = fitLine(data, dataLen-fitLen, fitLen)
+	}
+
 	smoothedData := make([]float64, dataLen)
 
 	for i := 0; i < dataLen; i++ {
@@ -102,9 +186,28 @@ func (ms *ModifiedSincSmoother) Smooth(data []float64) ([]float64, error) {
 
 			if dataIdx >= 0 && dataIdx < dataLen {
 				sum += data[dataIdx] * ms.kernel[k]
+				continue
+			}
+
+			switch ms.boundary {
+			case BoundaryLinearExtrap:
+				if dataIdx < 0 {
+					sum += (aLeft + bLeft*float64(dataIdx)) * ms.kernel[k]
+				} else {
+					sum += (aRight + bRight*float64(dataIdx)) * ms.kernel[k]
+				}
+			case BoundaryReflect:
+				reflected := dataIdx
+				if reflected < 0 {
+					reflected = -reflected
+				} else if reflected >= dataLen {
+					reflected = 2*(dataLen-1) - reflected
+				}
+				if reflected >= 0 && reflected < dataLen {
+					sum += data[reflected] * ms.kernel[k]
+				}
+			default: // BoundaryZero: implicit zero-padding, term contributes nothing.
 			}
-			// Implicit zero-padding: if dataIdx is out of bounds, data[dataIdx]*kernel[k] is not added.
-			// The paper mentions linear extrapolation for boundary handling which is more advanced. [cite: 181]
 		}
 		smoothedData[i] = sum
 	}
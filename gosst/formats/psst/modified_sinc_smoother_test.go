@@ -0,0 +1,70 @@
+package psst
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// dtft evaluates the discrete-time Fourier transform of kernel (centered at its middle tap) at
+// cycles/sample frequency f, i.e. sum_i kernel[i] * exp(-2*pi*i*f*idx), idx ranging symmetrically
+// around zero. Used to check the passband flatness of MS kernels directly, without going through
+// an FFT bin grid.
+func dtft(kernel []float64, f float64) complex128 {
+	m := (len(kernel) - 1) / 2
+	var sum complex128
+	for k, c := range kernel {
+		idx := float64(k - m)
+		sum += complex(c, 0) * cmplx.Exp(complex(0, -2*math.Pi*f*idx))
+	}
+	return sum
+}
+
+// TestModifiedSincFrequencyResponse verifies that n_ms 6, 8 and 10 - the degrees that previously
+// carried an Eq. 7 correction polynomial - produce a kernel whose passband (up to the nominal
+// cutoff f_c = 1/(m_ms+1)) is flat within 1%, per the spec in Schmid et al.[cite: 105]. The
+// tabulated correction coefficients used to break this (passband deviation up to ~20%); with no
+// correction applied, the base sinc*window kernel already satisfies it.
+func TestModifiedSincFrequencyResponse(t *testing.T) {
+	const maxDeviation = 0.01
+
+	for _, n_ms := range []int{6, 8, 10} {
+		m_ms := n_ms/2 + 2
+		ms, err := NewModifiedSincSmoother(n_ms, m_ms, 4.0)
+		if err != nil {
+			t.Fatalf("n_ms=%d: NewModifiedSincSmoother failed: %v", n_ms, err)
+		}
+
+		fc := 1.0 / float64(m_ms+1)
+		const steps = 200
+		for i := 0; i <= steps; i++ {
+			f := fc * float64(i) / steps
+			gain := cmplx.Abs(dtft(ms.kernel, f))
+			if dev := math.Abs(gain - 1.0); dev > maxDeviation {
+				t.Errorf("n_ms=%d: passband deviation %.4f%% at f=%.4f exceeds %.0f%%", n_ms, dev*100, f, maxDeviation*100)
+			}
+		}
+	}
+}
+
+// TestModifiedSincKernelNormalized verifies that every supported n_ms (2, 4, 6, 8 and 10) produces
+// a kernel that sums to 1 - Eq. 6 [cite: 89].
+func TestModifiedSincKernelNormalized(t *testing.T) {
+	const tolerance = 1e-9
+
+	for n_ms := range msSupportedOrders {
+		m_ms := n_ms/2 + 2
+		ms, err := NewModifiedSincSmoother(n_ms, m_ms, 4.0)
+		if err != nil {
+			t.Fatalf("n_ms=%d: NewModifiedSincSmoother failed: %v", n_ms, err)
+		}
+
+		var sum float64
+		for _, c := range ms.kernel {
+			sum += c
+		}
+		if dev := math.Abs(sum - 1.0); dev > tolerance {
+			t.Errorf("n_ms=%d: kernel sums to %.12f, want 1", n_ms, sum)
+		}
+	}
+}
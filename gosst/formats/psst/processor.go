@@ -0,0 +1,278 @@
+package psst
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProcessorWindowSamples is the default size (in samples) of the sliding window a Processor
+// smooths and searches for strokes in. It must cover at least the WH smoother's effective FWHM
+// plus enough lookahead for stroke detection to confirm a stroke has ended; 128 samples at a
+// typical 1 kHz datalogger rate comfortably covers both (see the WH_LAMBDA table in psst.go).
+const ProcessorWindowSamples = 128
+
+// processorStrokeLookahead is how many trailing samples of a Push's window are treated as
+// still-open, i.e. not yet committed. A stroke ending in this margin is included in Snapshot but
+// might still be extended by the next Push.
+const processorStrokeLookahead = 32
+
+// Processor ingests front/rear samples incrementally (e.g. live off a datalogger) and maintains
+// a windowed Processed view, in contrast to ProcessRecording which requires the full recording
+// up front. It holds a sliding window of the most recent samples, re-smoothing and re-deriving
+// velocity over that window on every Push so strokes and airtimes can be streamed out as they
+// close, without reprocessing the whole recording.
+type Processor[T Number] struct {
+	pd     *Processed
+	window int
+
+	frontRaw []T
+	rearRaw  []T
+
+	frontDone []*stroke // strokes (idlings included) fully committed from earlier windows, session-relative Start/End
+	rearDone  []*stroke
+
+	lastTimestamp *time.Time
+}
+
+// NewProcessor creates a Processor for a single recording session, using the same Meta and
+// SetupData ProcessRecording would be given.
+func NewProcessor[T Number](meta Meta, setup *SetupData) (*Processor[T], error) {
+	if setup == nil || setup.Linkage == nil || setup.FrontCalibration == nil || setup.RearCalibration == nil {
+		return nil, errors.New("Processor: SetupData ist unvollständig")
+	}
+
+	pd := &Processed{Meta: meta, Linkage: *setup.Linkage}
+	pd.Front.Calibration = *setup.FrontCalibration
+	pd.Rear.Calibration = *setup.RearCalibration
+
+	return &Processor[T]{pd: pd, window: ProcessorWindowSamples}, nil
+}
+
+// Push feeds the next batch of raw front/rear samples into the processor. Use PushAt instead
+// when wall-clock timestamps are available and dropped samples should be detected.
+func (p *Processor[T]) Push(front, rear []T) error {
+	return p.push(front, rear, nil)
+}
+
+// PushAt is like Push but additionally records the wall-clock time the batch was captured at.
+// If the gap to the previous batch's timestamp is larger than Meta.SampleRate would predict for
+// len(front) samples, the missing samples are assumed dropped and zero-padded, analogous to how
+// a poll-driven control loop recomputes dt from a monotonic clock and re-synchronizes on stale
+// data instead of silently drifting.
+func (p *Processor[T]) PushAt(front, rear []T, timestamp time.Time) error {
+	return p.push(front, rear, &timestamp)
+}
+
+func (p *Processor[T]) push(front, rear []T, timestamp *time.Time) error {
+	if len(front) != len(rear) && len(front) != 0 && len(rear) != 0 {
+		return fmt.Errorf("Processor: front-Batch (%d) und rear-Batch (%d) müssen gleich lang sein", len(front), len(rear))
+	}
+
+	if timestamp != nil {
+		if p.lastTimestamp != nil && p.pd.Meta.SampleRate > 0 {
+			expected := time.Duration(float64(len(front)) / float64(p.pd.Meta.SampleRate) * float64(time.Second))
+			if gap := timestamp.Sub(*p.lastTimestamp); gap > expected {
+				dropped := int((gap - expected).Seconds() * float64(p.pd.Meta.SampleRate))
+				if dropped > 0 {
+					p.frontRaw = append(p.frontRaw, make([]T, dropped)...)
+					p.rearRaw = append(p.rearRaw, make([]T, dropped)...)
+				}
+			}
+		}
+		p.lastTimestamp = timestamp
+	}
+
+	p.frontRaw = append(p.frontRaw, front...)
+	p.rearRaw = append(p.rearRaw, rear...)
+
+	if err := p.refreshFront(); err != nil {
+		return fmt.Errorf("Processor: Front konnte nicht aktualisiert werden: %w", err)
+	}
+	if err := p.refreshRear(); err != nil {
+		return fmt.Errorf("Processor: Rear konnte nicht aktualisiert werden: %w", err)
+	}
+	return nil
+}
+
+// windowStart returns the index into raw at which the trailing window of size p.window begins.
+func (p *Processor[T]) windowStart(rawLen int) int {
+	if start := rawLen - p.window; start > 0 {
+		return start
+	}
+	return 0
+}
+
+func (p *Processor[T]) refreshFront() error {
+	fc := len(p.frontRaw)
+	if fc == 0 {
+		return nil
+	}
+	p.pd.Front.Present = true
+
+	start := p.windowStart(fc)
+	travel := calibrateFrontTravel(p.frontRaw[start:], &p.pd.Front.Calibration, p.pd.Linkage.HeadAngle, p.pd.Linkage.MaxFrontTravel)
+
+	velocity, err := p.smoothWindow(travel)
+	if err != nil {
+		return err
+	}
+
+	p.pd.Front.Travel = accumulateWindow(p.pd.Front.Travel, travel, start)
+	p.pd.Front.Velocity = accumulateWindow(p.pd.Front.Velocity, velocity, start)
+
+	windowStrokes := filterStrokes(velocity, travel, p.pd.Linkage.MaxFrontTravel, p.pd.Meta.SampleRate)
+	offsetStrokes(windowStrokes, start)
+	var stillOpen []*stroke
+	p.frontDone, stillOpen = commitClosedStrokes(p.frontDone, windowStrokes, fc-processorStrokeLookahead)
+
+	allStrokes := append(append([]*stroke{}, p.frontDone...), stillOpen...)
+	p.pd.Front.Strokes.categorize(allStrokes, p.pd.Front.Travel, p.pd.Linkage.MaxFrontTravel)
+	p.digitizeStrokes(&p.pd.Front, p.pd.Linkage.MaxFrontTravel)
+
+	return nil
+}
+
+func (p *Processor[T]) refreshRear() error {
+	rc := len(p.rearRaw)
+	if rc == 0 {
+		return nil
+	}
+	p.pd.Rear.Present = true
+
+	start := p.windowStart(rc)
+	travel := calibrateRearTravel(p.rearRaw[start:], &p.pd.Rear.Calibration, &p.pd.Linkage)
+
+	velocity, err := p.smoothWindow(travel)
+	if err != nil {
+		return err
+	}
+
+	p.pd.Rear.Travel = accumulateWindow(p.pd.Rear.Travel, travel, start)
+	p.pd.Rear.Velocity = accumulateWindow(p.pd.Rear.Velocity, velocity, start)
+
+	windowStrokes := filterStrokes(velocity, travel, p.pd.Linkage.MaxRearTravel, p.pd.Meta.SampleRate)
+	offsetStrokes(windowStrokes, start)
+	var stillOpen []*stroke
+	p.rearDone, stillOpen = commitClosedStrokes(p.rearDone, windowStrokes, rc-processorStrokeLookahead)
+
+	allStrokes := append(append([]*stroke{}, p.rearDone...), stillOpen...)
+	p.pd.Rear.Strokes.categorize(allStrokes, p.pd.Rear.Travel, p.pd.Linkage.MaxRearTravel)
+	p.digitizeStrokes(&p.pd.Rear, p.pd.Linkage.MaxRearTravel)
+
+	return nil
+}
+
+// accumulateWindow merges a freshly recomputed trailing window into a session-accumulated
+// series: positions before start are left untouched (already finalized by earlier windows),
+// positions from start onward are replaced with the new window's values. session is zero-padded
+// first if it is shorter than start, which can only happen if a single Push batch is larger than
+// the window itself.
+func accumulateWindow(session, window []float64, start int) []float64 {
+	if start > len(session) {
+		session = append(session, make([]float64, start-len(session))...)
+	}
+	return append(session[:start], window...)
+}
+
+// offsetStrokes shifts stroke Start/End from window-local indices (relative to where the window
+// begins in frontRaw/rearRaw) to absolute session-relative indices, so strokes committed across
+// different, differently-positioned windows dedupe correctly and index into the session-
+// accumulated Travel/Velocity built by accumulateWindow rather than into whichever window they
+// were originally detected in.
+func offsetStrokes(strokes []*stroke, offset int) {
+	if offset == 0 {
+		return
+	}
+	for _, s := range strokes {
+		s.Start += offset
+		s.End += offset
+	}
+}
+
+// digitizeStrokes rebuilds the travel/velocity histogram bins over the session-accumulated
+// Travel/Velocity and populates DigitizedTravel/DigitizedVelocity/FineDigitizedVelocity for side's
+// strokes, mirroring the dtFront/dv/dvFine setup in ProcessRecording.
+func (p *Processor[T]) digitizeStrokes(side *suspension, maxTravel float64) {
+	if maxTravel <= 0 {
+		return
+	}
+	tbins := linspace(0, maxTravel, TRAVEL_HIST_BINS+1)
+	side.TravelBins = tbins
+	dt := digitize(side.Travel, tbins)
+
+	vbins, dv := digitizeVelocity(side.Velocity, VELOCITY_HIST_STEP)
+	side.VelocityBins = vbins
+	vbinsFine, dvFine := digitizeVelocity(side.Velocity, VELOCITY_HIST_STEP_FINE)
+	side.FineVelocityBins = vbinsFine
+
+	side.Strokes.digitize(dt, dv, dvFine)
+}
+
+// smoothWindow runs WH smoothing plus derivative over a single window, falling back to zero
+// velocity when the window is still too short for the configured WH_ORDER (mirrors
+// ProcessRecording's guard for short recordings).
+func (p *Processor[T]) smoothWindow(travel []float64) ([]float64, error) {
+	if len(travel) < WH_ORDER+1 || p.pd.Meta.SampleRate == 0 {
+		return make([]float64, len(travel)), nil
+	}
+	whs, err := NewWhittakerHendersonSmoother(len(travel), WH_ORDER, WH_LAMBDA)
+	if err != nil {
+		return make([]float64, len(travel)), nil
+	}
+	smoothed, err := whs.Smooth(travel)
+	if err != nil {
+		return make([]float64, len(travel)), nil
+	}
+	return calculateDerivative(smoothed, p.pd.Meta.SampleRate)
+}
+
+// commitClosedStrokes splits windowStrokes (already offset to session-relative indices) against
+// done, the strokes committed from earlier, overlapping windows: strokes ending before cutoff are
+// appended to done, the rest are returned as stillOpen. windowStrokes covering samples already
+// folded into done are dropped rather than re-committed: every window recomputes strokes across
+// its entire span, not just the portion not yet committed, and its merge heuristic (see
+// filterStrokes) can draw a slightly different boundary for the same physical stroke depending on
+// what precedes it in that window's view - re-adding it under its new, possibly overlapping
+// Start/End would both duplicate and contradict the already-committed version.
+func commitClosedStrokes(done, windowStrokes []*stroke, cutoff int) (newDone, stillOpen []*stroke) {
+	committedThrough := 0
+	if n := len(done); n > 0 {
+		committedThrough = done[n-1].End + 1
+	}
+	for _, s := range windowStrokes {
+		if s.Start < committedThrough {
+			continue
+		}
+		if cutoff > 0 && s.End < cutoff {
+			done = append(done, s)
+			committedThrough = s.End + 1
+		} else {
+			stillOpen = append(stillOpen, s)
+		}
+	}
+	return done, stillOpen
+}
+
+// Snapshot returns the Processed state as of the most recent Push, without closing the
+// recording: Travel/Velocity cover the full session seen so far, but only the trailing
+// ProcessorWindowSamples are re-smoothed on each Push, so that tail (and any stroke still open
+// within it) may be revised by subsequent pushes.
+func (p *Processor[T]) Snapshot() *Processed {
+	snapshot := *p.pd
+	return &snapshot
+}
+
+// Close finalizes the recording, committing any still-open stroke and deriving airtimes over
+// the full accumulated session, then returns the final Processed result. The Processor must not
+// be used after Close.
+func (p *Processor[T]) Close() (*Processed, error) {
+	if len(p.frontRaw) == 0 && len(p.rearRaw) == 0 {
+		return nil, &MissingRecordsError{}
+	}
+	return ProcessRecording[T](p.frontRaw, p.rearRaw, p.pd.Meta, &SetupData{
+		Linkage:          &p.pd.Linkage,
+		FrontCalibration: &p.pd.Front.Calibration,
+		RearCalibration:  &p.pd.Rear.Calibration,
+	}, nil)
+}
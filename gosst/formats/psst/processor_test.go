@@ -0,0 +1,79 @@
+package psst
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func newTestProcessor(t *testing.T) *Processor[float64] {
+	t.Helper()
+	linkage := &Linkage{HeadAngle: 90, MaxFrontStroke: 200}
+	linkage.MaxFrontTravel = math.Sin(linkage.HeadAngle*math.Pi/180.0) * linkage.MaxFrontStroke
+
+	setup := &SetupData{
+		Linkage:          linkage,
+		FrontCalibration: &Calibration{},
+		RearCalibration:  &Calibration{},
+	}
+	proc, err := NewProcessor[float64](Meta{SampleRate: 1000}, setup)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+	return proc
+}
+
+// TestProcessorCommittedStrokesSpanFullSession pushes a synthetic oscillating front travel
+// signal across many overlapping windows and checks that committed strokes carry session-
+// relative (not window-relative) Start/End: no duplicates, indices within session bounds and
+// strictly increasing across strokes, DigitizedTravel populated, and Snapshot's Travel covering
+// the whole session rather than just the trailing window.
+func TestProcessorCommittedStrokesSpanFullSession(t *testing.T) {
+	proc := newTestProcessor(t)
+
+	const n = 400
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 50 + 40*math.Sin(2*math.Pi*float64(i)/80.0)
+	}
+
+	const batch = 40
+	for i := 0; i < n; i += batch {
+		end := min(i+batch, n)
+		if err := proc.Push(data[i:end], nil); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+
+	snap := proc.Snapshot()
+	if len(snap.Front.Travel) != n {
+		t.Fatalf("Snapshot discarded session history: len(Front.Travel)=%d, want %d", len(snap.Front.Travel), n)
+	}
+
+	allStrokes := append(append([]*stroke{}, snap.Front.Strokes.Compressions...), snap.Front.Strokes.Rebounds...)
+	if len(allStrokes) == 0 {
+		t.Fatal("expected at least one committed stroke")
+	}
+	sort.Slice(allStrokes, func(i, j int) bool { return allStrokes[i].Start < allStrokes[j].Start })
+
+	seenStart := make(map[int]bool, len(allStrokes))
+	lastEnd := -1
+	for _, s := range allStrokes {
+		if seenStart[s.Start] {
+			t.Errorf("stroke with Start=%d committed more than once", s.Start)
+		}
+		seenStart[s.Start] = true
+
+		if s.Start < 0 || s.End >= n || s.Start > s.End {
+			t.Errorf("stroke Start=%d End=%d out of session bounds [0,%d)", s.Start, s.End, n)
+		}
+		if s.Start <= lastEnd {
+			t.Errorf("stroke Start=%d overlaps previous stroke ending at %d", s.Start, lastEnd)
+		}
+		lastEnd = s.End
+
+		if len(s.DigitizedTravel) != s.End-s.Start+1 {
+			t.Errorf("stroke Start=%d End=%d: len(DigitizedTravel)=%d, want %d", s.Start, s.End, len(s.DigitizedTravel), s.End-s.Start+1)
+		}
+	}
+}
@@ -103,6 +103,168 @@ func calculateDerivative(data []float64, sampleRate uint16) ([]float64, error) {
 	return derivative, nil
 }
 
+// calibrateFrontTravel converts raw front ADC samples to travel (mm), applying the head-angle
+// projection and clamping to [0, maxFrontTravel]. Shared by ProcessRecording and Processor so
+// both the one-shot and streaming ingest paths calibrate samples identically.
+func calibrateFrontTravel[T Number](front []T, cal *Calibration, headAngleDeg, maxFrontTravel float64) []float64 {
+	travel := make([]float64, len(front))
+	coeff := math.Sin(headAngleDeg * math.Pi / 180.0)
+	for idx, value := range front {
+		out, _ := cal.Evaluate(float64(value))
+		x := out * coeff
+		x = math.Max(0, x)
+		x = math.Min(x, maxFrontTravel)
+		travel[idx] = x
+	}
+	return travel
+}
+
+// calibrateRearTravel converts raw rear ADC samples to travel (mm) via the linkage's
+// shock-to-wheel polynomial, clamping to [0, linkage.MaxRearTravel]. Shared by ProcessRecording
+// and Processor.
+func calibrateRearTravel[T Number](rear []T, cal *Calibration, linkage *Linkage) []float64 {
+	travel := make([]float64, len(rear))
+	for idx, value := range rear {
+		out, _ := cal.Evaluate(float64(value))
+		x := linkage.polynomial.At(out)
+		x = math.Max(0, x)
+		x = math.Min(x, linkage.MaxRearTravel)
+		travel[idx] = x
+	}
+	return travel
+}
+
+// Smoother smooths a single travel series, producing velocity's input in place of the default
+// Whittaker-Henderson filter ProcessRecording otherwise builds per side. Implementations are
+// expected to already be sized (or otherwise able) to smooth the series they'll actually be given;
+// ProcessRecording calls Smooth separately for front and rear, so a Smoother that can't handle one
+// side's length should return an error from Smooth rather than panic.
+type Smoother interface {
+	Smooth(data []float64) ([]float64, error)
+}
+
+// PassThroughSmoother is a Smoother that returns its input unchanged, for recordings that have
+// already been filtered upstream (e.g. on the datalogger) and shouldn't be smoothed again.
+type PassThroughSmoother struct{}
+
+func (PassThroughSmoother) Smooth(data []float64) ([]float64, error) {
+	out := make([]float64, len(data))
+	copy(out, data)
+	return out, nil
+}
+
+// ProcessOptions configures optional behaviour of ProcessRecording. A nil *ProcessOptions, or a
+// nil field within one, falls back to the historical defaults: a WH_ORDER/WH_LAMBDA smoother built
+// fresh per side, with warnings only recorded on Processed.ProcessWarnings.
+type ProcessOptions struct {
+	// Smoother, if set, replaces the default Whittaker-Henderson smoother for both front and rear.
+	// See NewWHSmootherForCutoff to build one from a target -3dB cutoff frequency instead of a raw
+	// lambda.
+	Smoother Smoother
+	// Logger, if set, is called with each warning as it happens, in addition to it being appended
+	// to Processed.ProcessWarnings.
+	Logger func(string)
+	// HarshCompressionPercentile sets the percentile of the recording's positive velocity
+	// distribution a compression stroke's peak velocity must exceed to count as a harsh
+	// compression (see suspension.HarshCompressions). Zero defaults to the 99th percentile.
+	HarshCompressionPercentile float64
+}
+
+// defaultHarshCompressionPercentile is the HarshCompressionPercentile used when unset.
+const defaultHarshCompressionPercentile = 0.99
+
+func harshCompressionPercentile(opts *ProcessOptions) float64 {
+	if opts != nil && opts.HarshCompressionPercentile > 0 {
+		return opts.HarshCompressionPercentile
+	}
+	return defaultHarshCompressionPercentile
+}
+
+func (opts *ProcessOptions) warn(pd *Processed, msg string) {
+	pd.ProcessWarnings = append(pd.ProcessWarnings, msg)
+	if opts != nil && opts.Logger != nil {
+		opts.Logger(msg)
+	}
+}
+
+func (opts *ProcessOptions) smootherFor(n int) (Smoother, error) {
+	if opts != nil && opts.Smoother != nil {
+		return opts.Smoother, nil
+	}
+	return NewWhittakerHendersonSmoother(n, WH_ORDER, WH_LAMBDA)
+}
+
+// whCutoffTableSampleRate is the sample rate the f_cutoff/WH_LAMBDA table above was measured at.
+const whCutoffTableSampleRate = 860.0
+
+// whCutoffLambdaTable is that table, in ascending cutoff order, for lambdaForCutoff to interpolate.
+var whCutoffLambdaTable = []struct {
+	CutoffHz float64
+	Lambda   float64
+}{
+	{10.0, 14600},
+	{12.5, 5900},
+	{15.0, 2900},
+	{17.5, 1550},
+	{20.0, 920},
+	{22.5, 580},
+	{25.0, 380},
+	{27.1, 272},
+	{27.5, 260},
+	{30.0, 185},
+	{32.5, 130},
+	{35.0, 100},
+	{37.5, 75},
+	{40.0, 60},
+}
+
+// lambdaForCutoff interpolates WH_LAMBDA for a target -3dB cutoffHz at sampleRate. The table is
+// rescaled from whCutoffTableSampleRate first: for fixed order and lambda, a WH filter's cutoff in
+// Hz scales linearly with sample rate, so the table lookup is done at the equivalent cutoff it
+// would need at whCutoffTableSampleRate. Interpolation between table points is done in log-lambda
+// space, since lambda spans decades much faster than cutoff does.
+func lambdaForCutoff(cutoffHz, sampleRate float64) (float64, error) {
+	if cutoffHz <= 0 {
+		return 0, errors.New("WH Smoother: cutoffHz muss größer als 0 sein")
+	}
+	if sampleRate <= 0 {
+		return 0, errors.New("WH Smoother: sampleRate muss größer als 0 sein")
+	}
+
+	tableTarget := cutoffHz * whCutoffTableSampleRate / sampleRate
+	tbl := whCutoffLambdaTable
+	if tableTarget <= tbl[0].CutoffHz {
+		return tbl[0].Lambda, nil
+	}
+	if last := tbl[len(tbl)-1]; tableTarget >= last.CutoffHz {
+		return last.Lambda, nil
+	}
+	for i := 1; i < len(tbl); i++ {
+		hi := tbl[i]
+		if tableTarget > hi.CutoffHz {
+			continue
+		}
+		lo := tbl[i-1]
+		frac := (tableTarget - lo.CutoffHz) / (hi.CutoffHz - lo.CutoffHz)
+		logLambda := math.Log(lo.Lambda) + frac*(math.Log(hi.Lambda)-math.Log(lo.Lambda))
+		return math.Exp(logLambda), nil
+	}
+	return tbl[len(tbl)-1].Lambda, nil
+}
+
+// NewWHSmootherForCutoff builds a Whittaker-Henderson smoother of order WH_ORDER whose lambda is
+// chosen via lambdaForCutoff to give roughly the target -3dB cutoffHz at sampleRate.
+func NewWHSmootherForCutoff(length int, cutoffHz float64, sampleRate uint16) (*WhittakerHendersonSmoother, error) {
+	if sampleRate == 0 {
+		return nil, errors.New("WH Smoother: SampleRate darf nicht Null sein")
+	}
+	lambda, err := lambdaForCutoff(cutoffHz, float64(sampleRate))
+	if err != nil {
+		return nil, err
+	}
+	return NewWhittakerHendersonSmoother(length, WH_ORDER, lambda)
+}
+
 type LinkageRecord struct {
 	ShockTravel   float64
 	WheelTravel   float64
@@ -135,6 +297,11 @@ type suspension struct {
 	GlobalMaxTravelAllData float64
 	GlobalP95TravelAllData float64
 	GlobalAvgTravelAllData float64
+
+	Bottomouts           []*bottomout
+	BottomoutRate        float64 // bottomouts per minute of active (non-idling) travel
+	HarshCompressions    []*harshEvent
+	HarshCompressionRate float64 // harsh compressions per minute of active (non-idling) travel
 }
 
 type Number interface {
@@ -161,6 +328,13 @@ type Processed struct {
 	Linkage  Linkage
 	Airtimes []*airtime
 
+	// ProcessWarnings collects non-fatal issues ProcessRecording recovered from by falling back to
+	// zero velocity (e.g. a failed smoother or a zero sample rate), in the order encountered, so
+	// callers can surface them instead of losing them to stdout. See ProcessOptions.Logger for a
+	// way to also receive them as they happen.
+	ProcessWarnings []string
+}
+
 func (this *Linkage) ProcessRawData() error {
 	var records []LinkageRecord
 	scanner := bufio.NewScanner(strings.NewReader(this.RawData))
@@ -224,7 +398,45 @@ func (e *MissingRecordsError) Error() string { return "Front- und Rear-Record-Ar
 // type RecordCountMismatchError struct{}
 // func (e *RecordCountMismatchError) Error() string { return "Number of Front and Rear records does not match" }
 
-func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*Processed, error) {
+// computeVelocity smooths travel via opts' Smoother (or the default WH_ORDER/WH_LAMBDA smoother)
+// and differentiates it into velocity, recording a warning on pd and falling back to zero velocity
+// at any failure. side is used only to word the warning ("front"/"rear").
+func computeVelocity(travel []float64, sampleRate uint16, opts *ProcessOptions, pd *Processed, side string) []float64 {
+	n := len(travel)
+	minPointsForWH := WH_ORDER + 1
+	if n < minPointsForWH {
+		opts.warn(pd, fmt.Sprintf("Warning: Not enough %s data points (%d) for WH smoother (minimum %d required for order %d). Using zero velocity instead.", side, n, minPointsForWH, WH_ORDER))
+		return make([]float64, n)
+	}
+	if sampleRate == 0 {
+		opts.warn(pd, fmt.Sprintf("Warning: %s sample rate is zero; velocity cannot be computed. Using zero velocity instead.", side))
+		return make([]float64, n)
+	}
+
+	smoother, errSmoother := opts.smootherFor(n)
+	if errSmoother != nil {
+		opts.warn(pd, fmt.Sprintf("Warning: Failed to create smoother for %s travel: %v. Using zero velocity instead.", side, errSmoother))
+		return make([]float64, n)
+	}
+
+	smoothedTravel, errSmooth := smoother.Smooth(travel)
+	if errSmooth != nil {
+		opts.warn(pd, fmt.Sprintf("Warning: Error smoothing %s travel data: %v. Using zero velocity instead.", side, errSmooth))
+		return make([]float64, n)
+	}
+
+	velocity, errVel := calculateDerivative(smoothedTravel, sampleRate)
+	if errVel != nil {
+		opts.warn(pd, fmt.Sprintf("Warning: Error calculating %s velocity: %v. Using zero velocity instead.", side, errVel))
+		return make([]float64, n)
+	}
+	return velocity
+}
+
+// ProcessRecording runs the full one-shot processing pipeline over a complete front/rear
+// recording. opts may be nil to use the historical defaults (a WH_ORDER/WH_LAMBDA smoother built
+// per side, warnings only available via Processed.ProcessWarnings); see ProcessOptions.
+func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData, opts *ProcessOptions) (*Processed, error) {
 	var pd Processed
 	pd.Meta = meta
 	pd.Front.Calibration = *setup.FrontCalibration
@@ -241,15 +453,7 @@ func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*
 	}
 
 	if pd.Front.Present {
-		pd.Front.Travel = make([]float64, fc)
-		front_coeff := math.Sin(pd.Linkage.HeadAngle * math.Pi / 180.0)
-		for idx, value := range front {
-			out, _ := pd.Front.Calibration.Evaluate(float64(value))
-			x := out * front_coeff
-			x = math.Max(0, x)
-			x = math.Min(x, pd.Linkage.MaxFrontTravel)
-			pd.Front.Travel[idx] = x
-		}
+		pd.Front.Travel = calibrateFrontTravel(front, &pd.Front.Calibration, pd.Linkage.HeadAngle, pd.Linkage.MaxFrontTravel)
 
 		if len(pd.Front.Travel) > 0 {
 			pd.Front.GlobalMaxTravelAllData = floats.Max(pd.Front.Travel)
@@ -268,36 +472,7 @@ func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*
 		}
 		// pd.Front.Strokes.digitizeTravel(dtFront) // legacy call
 
-		minPointsForWH := WH_ORDER + 1
-		if fc >= minPointsForWH && pd.Meta.SampleRate > 0 {
-			whsFront, errWhs := NewWhittakerHendersonSmoother(fc, WH_ORDER, WH_LAMBDA)
-			if errWhs == nil {
-				smoothedTravel, errSmooth := whsFront.Smooth(pd.Front.Travel)
-				if errSmooth == nil {
-					velocity, errVel := calculateDerivative(smoothedTravel, pd.Meta.SampleRate)
-					if errVel == nil {
-						pd.Front.Velocity = velocity
-					} else {
-						fmt.Printf("Warning: Error calculating front velocity: %v. Using zero velocity instead.\n", errVel)
-						pd.Front.Velocity = make([]float64, fc)
-					}
-				} else {
-					fmt.Printf("Warning: Error smoothing front travel data: %v. Using zero velocity instead.\n", errSmooth)
-					pd.Front.Velocity = make([]float64, fc)
-				}
-			} else {
-				fmt.Printf("Warning: Failed to create WH smoother for front travel: %v. Using zero velocity instead.\n", errWhs)
-				pd.Front.Velocity = make([]float64, fc)
-			}
-		} else {
-			if fc < minPointsForWH {
-				fmt.Printf("Warning: Not enough front data points (%d) for WH smoother (minimum %d required for order %d). Using zero velocity instead.\n", fc, minPointsForWH, WH_ORDER)
-			}
-			if pd.Meta.SampleRate == 0 {
-				fmt.Printf("Warning: Front sample rate is zero; velocity cannot be computed. Using zero velocity instead.\n")
-			}
-			pd.Front.Velocity = make([]float64, fc)
-		}
+		pd.Front.Velocity = computeVelocity(pd.Front.Travel, pd.Meta.SampleRate, opts, &pd, "front")
 
 		vbins, dv := digitizeVelocity(pd.Front.Velocity, VELOCITY_HIST_STEP)
 		pd.Front.VelocityBins = vbins
@@ -313,17 +488,18 @@ func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*
 			// pd.Front.Strokes.digitizeVelocity(dv, dvFine) // legacy call
 			pd.Front.Strokes.digitize(dtFront, dv, dvFine)
 		}
+
+		pd.Front.Bottomouts = findBottomouts(pd.Front.Travel, pd.Front.Velocity, pd.Linkage.MaxFrontTravel, pd.Meta.SampleRate)
+		harshThreshold := positiveVelocityPercentile(pd.Front.Velocity, pd.Front.FineVelocityBins, dvFine, harshCompressionPercentile(opts))
+		pd.Front.HarshCompressions = findHarshCompressions(pd.Front.Strokes.Compressions, harshThreshold)
+		if minutes := activeDurationMinutes(pd.Front.Strokes); minutes > 0 {
+			pd.Front.BottomoutRate = float64(len(pd.Front.Bottomouts)) / minutes
+			pd.Front.HarshCompressionRate = float64(len(pd.Front.HarshCompressions)) / minutes
+		}
 	}
 
 	if pd.Rear.Present {
-		pd.Rear.Travel = make([]float64, rc)
-		for idx, value := range rear {
-			out, _ := pd.Rear.Calibration.Evaluate(float64(value))
-			x := pd.Linkage.polynomial.At(out)
-			x = math.Max(0, x)
-			x = math.Min(x, pd.Linkage.MaxRearTravel)
-			pd.Rear.Travel[idx] = x
-		}
+		pd.Rear.Travel = calibrateRearTravel(rear, &pd.Rear.Calibration, &pd.Linkage)
 
 		if len(pd.Rear.Travel) > 0 {
 			pd.Rear.GlobalMaxTravelAllData = floats.Max(pd.Rear.Travel)
@@ -342,36 +518,7 @@ func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*
 		}
 		// pd.Rear.Strokes.digitizeTravel(dtRear) // legacy call
 
-		minPointsForWH := WH_ORDER + 1
-		if rc >= minPointsForWH && pd.Meta.SampleRate > 0 {
-			whsRear, errWhs := NewWhittakerHendersonSmoother(rc, WH_ORDER, WH_LAMBDA)
-			if errWhs == nil {
-				smoothedTravel, errSmooth := whsRear.Smooth(pd.Rear.Travel)
-				if errSmooth == nil {
-					velocity, errVel := calculateDerivative(smoothedTravel, pd.Meta.SampleRate)
-					if errVel == nil {
-						pd.Rear.Velocity = velocity
-					} else {
-						fmt.Printf("Warning: Error calculating rear velocity: %v. Using zero velocity instead.\n", errVel)
-						pd.Rear.Velocity = make([]float64, rc)
-					}
-				} else {
-					fmt.Printf("Warning: Error smoothing rear travel data: %v. Using zero velocity instead.\n", errSmooth)
-					pd.Rear.Velocity = make([]float64, rc)
-				}
-			} else {
-				fmt.Printf("Warning: Failed to create WH smoother for rear travel: %v. Using zero velocity instead.\n", errWhs)
-				pd.Rear.Velocity = make([]float64, rc)
-			}
-		} else {
-			if rc < minPointsForWH {
-				fmt.Printf("Warning: Not enough rear data points (%d) for WH smoother (minimum %d required for order %d). Using zero velocity instead.\n", rc, minPointsForWH, WH_ORDER)
-			}
-			if pd.Meta.SampleRate == 0 {
-				fmt.Printf("Warning: Rear sample rate is zero; velocity cannot be computed. Using zero velocity instead.\n")
-			}
-			pd.Rear.Velocity = make([]float64, rc)
-		}
+		pd.Rear.Velocity = computeVelocity(pd.Rear.Travel, pd.Meta.SampleRate, opts, &pd, "rear")
 
 		vbins, dv := digitizeVelocity(pd.Rear.Velocity, VELOCITY_HIST_STEP)
 		pd.Rear.VelocityBins = vbins
@@ -386,6 +533,14 @@ func ProcessRecording[T Number](front, rear []T, meta Meta, setup *SetupData) (*
 			// pd.Rear.Strokes.digitizeVelocity(dv, dvFine) // legacy call
 			pd.Rear.Strokes.digitize(dtRear, dv, dvFine)
 		}
+
+		pd.Rear.Bottomouts = findBottomouts(pd.Rear.Travel, pd.Rear.Velocity, pd.Linkage.MaxRearTravel, pd.Meta.SampleRate)
+		harshThreshold := positiveVelocityPercentile(pd.Rear.Velocity, pd.Rear.FineVelocityBins, dvFine, harshCompressionPercentile(opts))
+		pd.Rear.HarshCompressions = findHarshCompressions(pd.Rear.Strokes.Compressions, harshThreshold)
+		if minutes := activeDurationMinutes(pd.Rear.Strokes); minutes > 0 {
+			pd.Rear.BottomoutRate = float64(len(pd.Rear.Bottomouts)) / minutes
+			pd.Rear.HarshCompressionRate = float64(len(pd.Rear.HarshCompressions)) / minutes
+		}
 	}
 
 	pd.airtimes()
@@ -0,0 +1,93 @@
+package psst
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// measureGain feeds a sampleRate-periodic sinusoid of frequency freqHz through smoother and
+// returns the ratio of the smoothed amplitude to the input amplitude, measured via RMS over the
+// middle half of the series (trimming both ends to avoid boundary effects the WH smoother's
+// finite-difference penalty doesn't fully suppress).
+func measureGain(t *testing.T, smoother Smoother, n int, freqHz, sampleRate float64) float64 {
+	t.Helper()
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = math.Sin(2 * math.Pi * freqHz * float64(i) / sampleRate)
+	}
+	out, err := smoother.Smooth(data)
+	if err != nil {
+		t.Fatalf("Smooth failed: %v", err)
+	}
+
+	lo, hi := n/4, 3*n/4
+	var inSumSq, outSumSq float64
+	for i := lo; i < hi; i++ {
+		inSumSq += data[i] * data[i]
+		outSumSq += out[i] * out[i]
+	}
+	return math.Sqrt(outSumSq / inSumSq)
+}
+
+// TestNewWHSmootherForCutoffMatchesTargetCutoff checks that a smoother built by
+// NewWHSmootherForCutoff actually attenuates a sinusoid at the requested cutoffHz to roughly
+// -3dB (amplitude ratio ~0.71), and passes a much lower frequency through largely unattenuated,
+// for a couple of different sample rates. Both sample rates keep cutoffHz within the range the
+// whCutoffLambdaTable actually covers once rescaled to whCutoffTableSampleRate (860); well
+// outside that range the table clamps to its nearest entry instead of extrapolating, which is a
+// coarser approximation than this test's tolerance allows for.
+func TestNewWHSmootherForCutoffMatchesTargetCutoff(t *testing.T) {
+	const n = 4000
+	const cutoffHz = 20.0
+
+	for _, sampleRate := range []uint16{860, 1000} {
+		smoother, err := NewWHSmootherForCutoff(n, cutoffHz, sampleRate)
+		if err != nil {
+			t.Fatalf("sampleRate=%d: NewWHSmootherForCutoff failed: %v", sampleRate, err)
+		}
+
+		lowGain := measureGain(t, smoother, n, cutoffHz/10, float64(sampleRate))
+		if lowGain < 0.9 {
+			t.Errorf("sampleRate=%d: gain at cutoffHz/10 = %.3f, want close to 1 (passband)", sampleRate, lowGain)
+		}
+
+		cutoffGain := measureGain(t, smoother, n, cutoffHz, float64(sampleRate))
+		const want = 0.71 // -3dB
+		const tolerance = 0.15
+		if d := math.Abs(cutoffGain - want); d > tolerance {
+			t.Errorf("sampleRate=%d: gain at cutoffHz=%.1f = %.3f, want %.2f +/- %.2f", sampleRate, cutoffHz, cutoffGain, want, tolerance)
+		}
+	}
+}
+
+// failingSmoother is a Smoother that always errors, for exercising computeVelocity's fallback
+// path.
+type failingSmoother struct{}
+
+func (failingSmoother) Smooth(data []float64) ([]float64, error) {
+	return nil, errors.New("synthetic smoother failure")
+}
+
+// TestComputeVelocityWarnsOnFailingSmoother checks that computeVelocity - on a Smoother that
+// fails - both falls back to zero velocity (so callers never see partially-differentiated
+// garbage) and records the failure on pd.ProcessWarnings, rather than swallowing it.
+func TestComputeVelocityWarnsOnFailingSmoother(t *testing.T) {
+	var pd Processed
+	opts := &ProcessOptions{Smoother: failingSmoother{}}
+	travel := make([]float64, 50)
+	for i := range travel {
+		travel[i] = float64(i)
+	}
+
+	velocity := computeVelocity(travel, 1000, opts, &pd, "front")
+
+	for i, v := range velocity {
+		if v != 0 {
+			t.Errorf("velocity[%d] = %v, want 0 after a failing smoother", i, v)
+		}
+	}
+	if len(pd.ProcessWarnings) == 0 {
+		t.Fatal("pd.ProcessWarnings is empty, want a warning about the failed smoother")
+	}
+}
@@ -42,6 +42,20 @@ type airtime struct {
 	End   float64
 }
 
+type bottomout struct {
+	Start        int
+	End          int
+	PeakTravel   float64
+	Dwell        float64 // (s)
+	PeakVelocity float64 // peak positive velocity in the compression leading into the bottom-out
+}
+
+type harshEvent struct {
+	Start        int
+	End          int
+	PeakVelocity float64
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -359,4 +373,108 @@ func filterStrokes(velocity, travel []float64, maxTravel float64, rate uint16) (
 		i = segmentEndIndex + 1 // 'i' für die nächste Iteration der äußeren Schleife setzen
 	}
 	return strokes
+}
+
+// findBottomouts returns each contiguous region where travel > maxTravel-BOTTOMOUT_THRESHOLD as a
+// first-class event, alongside the peak velocity of the compression that led into it.
+func findBottomouts(travel, velocity []float64, maxTravel float64, rate uint16) []*bottomout {
+	bottomouts := make([]*bottomout, 0)
+	if len(travel) == 0 || rate == 0 {
+		return bottomouts
+	}
+
+	threshold := maxTravel - BOTTOMOUT_THRESHOLD
+	for i := 0; i < len(travel); {
+		if travel[i] <= threshold {
+			i++
+			continue
+		}
+		start := i
+		for i < len(travel) && travel[i] > threshold {
+			i++
+		}
+		end := i - 1
+		bottomouts = append(bottomouts, &bottomout{
+			Start:        start,
+			End:          end,
+			PeakTravel:   floats.Max(travel[start : end+1]),
+			Dwell:        float64(end-start+1) / float64(rate),
+			PeakVelocity: peakEntryVelocity(velocity, start),
+		})
+	}
+	return bottomouts
+}
+
+// peakEntryVelocity walks backward from start while velocity stays positive (i.e. still
+// compressing) to find the peak velocity of the compression stroke that led into start.
+func peakEntryVelocity(velocity []float64, start int) float64 {
+	peak := 0.0
+	if start >= 0 && start < len(velocity) && velocity[start] > peak {
+		peak = velocity[start]
+	}
+	for j := start - 1; j >= 0 && velocity[j] > 0; j-- {
+		if velocity[j] > peak {
+			peak = velocity[j]
+		}
+	}
+	return peak
+}
+
+// positiveVelocityPercentile estimates the value at the given percentile of a recording's positive
+// velocity samples from its fine-grained velocity histogram (bins and the per-sample digitized bin
+// index from digitizeVelocity), rather than sorting the full velocity series again.
+func positiveVelocityPercentile(velocity, bins []float64, digitized []int, percentile float64) float64 {
+	if len(bins) < 2 || len(digitized) == 0 {
+		return 0
+	}
+
+	counts := make([]int, len(bins)-1)
+	total := 0
+	for k, idx := range digitized {
+		if k >= len(velocity) || velocity[k] <= 0 || idx < 0 || idx >= len(counts) {
+			continue
+		}
+		counts[idx]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := int(math.Ceil(percentile * float64(total)))
+	if target < 1 {
+		target = 1
+	}
+	cumulative := 0
+	for i, c := range counts {
+		cumulative += c
+		if cumulative >= target {
+			return bins[i+1]
+		}
+	}
+	return bins[len(bins)-1]
+}
+
+// findHarshCompressions flags the compression strokes whose peak velocity exceeds threshold.
+func findHarshCompressions(compressions []*stroke, threshold float64) []*harshEvent {
+	events := make([]*harshEvent, 0)
+	for _, s := range compressions {
+		if s.Stat.MaxVelocity > threshold {
+			events = append(events, &harshEvent{Start: s.Start, End: s.End, PeakVelocity: s.Stat.MaxVelocity})
+		}
+	}
+	return events
+}
+
+// activeDurationMinutes sums compression and rebound stroke durations (excluding idling), in
+// minutes, for normalizing bottom-out/harsh-compression counts into rates.
+func activeDurationMinutes(s strokes) float64 {
+	var total float64
+	for _, st := range s.Compressions {
+		total += st.duration
+	}
+	for _, st := range s.Rebounds {
+		total += st.duration
+	}
+	return total / 60.0
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package psst
+
+import (
+	"testing"
+)
+
+// TestFindBottomoutsDetectsDwellAndPeakVelocity builds a travel trace that rises into the
+// bottom-out band (travel > maxTravel-BOTTOMOUT_THRESHOLD), dwells there for a few samples, then
+// retreats, and checks that findBottomouts reports the expected start/end/dwell, plus the peak
+// velocity of the compression stroke that led into it (via peakEntryVelocity walking back while
+// velocity stays positive).
+func TestFindBottomoutsDetectsDwellAndPeakVelocity(t *testing.T) {
+	const rate = uint16(100)
+	const maxTravel = 150.0
+	threshold := maxTravel - BOTTOMOUT_THRESHOLD // 147.5
+
+	travel := []float64{0, 50, 100, 140, threshold + 1, threshold + 2, threshold + 1.5, 100, 50}
+	velocity := []float64{0, 500, 500, 400, 300, -50, -100, -500, -500}
+
+	bottomouts := findBottomouts(travel, velocity, maxTravel, rate)
+	if len(bottomouts) != 1 {
+		t.Fatalf("len(bottomouts) = %d, want 1", len(bottomouts))
+	}
+
+	bo := bottomouts[0]
+	if bo.Start != 4 || bo.End != 6 {
+		t.Errorf("bottomout span = [%d,%d], want [4,6]", bo.Start, bo.End)
+	}
+	wantDwell := 3.0 / float64(rate)
+	if bo.Dwell != wantDwell {
+		t.Errorf("Dwell = %v, want %v", bo.Dwell, wantDwell)
+	}
+	wantPeakTravel := threshold + 2
+	if bo.PeakTravel != wantPeakTravel {
+		t.Errorf("PeakTravel = %v, want %v", bo.PeakTravel, wantPeakTravel)
+	}
+	// peakEntryVelocity walks back from Start=4 while velocity stays positive: velocity[4]=300,
+	// velocity[3]=400, velocity[2]=500, velocity[1]=500, velocity[0]=0 (stops, not > 0).
+	const wantPeakVelocity = 500.0
+	if bo.PeakVelocity != wantPeakVelocity {
+		t.Errorf("PeakVelocity = %v, want %v", bo.PeakVelocity, wantPeakVelocity)
+	}
+}
+
+// TestFindBottomoutsThresholdIsExclusive pins down the travel[i] > threshold boundary: a sample
+// sitting exactly at maxTravel-BOTTOMOUT_THRESHOLD must NOT count as a bottom-out, only samples
+// strictly above it.
+func TestFindBottomoutsThresholdIsExclusive(t *testing.T) {
+	const rate = uint16(100)
+	const maxTravel = 150.0
+	threshold := maxTravel - BOTTOMOUT_THRESHOLD
+
+	travel := []float64{0, threshold, threshold, 0}
+	velocity := []float64{0, 100, 0, -100}
+	if bo := findBottomouts(travel, velocity, maxTravel, rate); len(bo) != 0 {
+		t.Errorf("samples exactly at threshold produced %d bottomouts, want 0", len(bo))
+	}
+
+	travel[2] = threshold + 0.01
+	if bo := findBottomouts(travel, velocity, maxTravel, rate); len(bo) != 1 {
+		t.Errorf("a sample strictly above threshold produced %d bottomouts, want 1", len(bo))
+	}
+}
+
+// TestFindHarshCompressionsFiltersByPeakVelocity checks that findHarshCompressions keeps only
+// the compressions whose Stat.MaxVelocity exceeds threshold, and that a stroke exactly at
+// threshold is excluded (the condition is a strict >).
+func TestFindHarshCompressionsFiltersByPeakVelocity(t *testing.T) {
+	const threshold = 1000.0
+	compressions := []*stroke{
+		{Start: 0, End: 10, Stat: strokestat{MaxVelocity: 500}},        // below
+		{Start: 20, End: 30, Stat: strokestat{MaxVelocity: threshold}}, // at threshold, excluded
+		{Start: 40, End: 50, Stat: strokestat{MaxVelocity: 1500}},      // above
+	}
+
+	events := findHarshCompressions(compressions, threshold)
+	if len(events) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(events))
+	}
+	if events[0].Start != 40 || events[0].End != 50 {
+		t.Errorf("event span = [%d,%d], want [40,50]", events[0].Start, events[0].End)
+	}
+	if events[0].PeakVelocity != 1500 {
+		t.Errorf("PeakVelocity = %v, want 1500", events[0].PeakVelocity)
+	}
+}
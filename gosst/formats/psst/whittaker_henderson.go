@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/lapack/lapack64"
+	"gonum.org/v1/gonum/mat"
 )
 
 // WH_MAX_ORDER ist die maximal unterstützte Ordnung für den WH-Filter, basierend auf DIFF_COEFF_WH.
@@ -20,15 +24,25 @@ var WH_DIFF_COEFF = [][]float64{
 	{-1, 5, -10, 10, -5, 1}, // Ordnung 5
 }
 
-// WhittakerHendersonSmoother speichert die vorberechnete Matrix für das Smoothing.
+// WhittakerHendersonSmoother speichert die Cholesky-Zerlegung von W + lambda*D'D für das
+// Smoothing. Die Zerlegung selbst übernimmt gonum/mat (mat.BandCholesky auf einer
+// mat.SymBandDense), sodass hier keine eigene Banddiagonal-Lösung mehr gepflegt werden muss.
+// mat.BandCholesky (nicht das dichte mat.Cholesky) ist entscheidend: es faktorisiert über
+// LAPACK dpbtrf auf dem gebänderten Speicher, statt das Band erst in eine volle n×n-Matrix zu
+// expandieren, und bleibt damit bei den hier relevanten Aufnahmelängen von 10k–200k Samples um
+// Größenordnungen schneller als der dichte Pfad (siehe BenchmarkWhittakerHendersonFactorizeDenseVsBanded).
 type WhittakerHendersonSmoother struct {
-	matrix [][]float64 // Die Cholesky-zerlegte Dreiecksmatrix (L)
-	length int         // Erwartete Länge der zu glättenden Daten
+	chol    *mat.BandCholesky
+	length  int       // Erwartete Länge der zu glättenden Daten
+	order   int       // Strafordnung p, für SmoothWeighted benötigt
+	lambda  float64   // Glättungsparameter, für SmoothWeighted benötigt
+	weights []float64 // nil im unweighted Fall; sonst die in chol einkodierten Gewichte
 }
 
-// makeDprimeDWH erstellt eine symmetrische Banddiagonalmatrix D'*D aus der Differenzmatrix D der p-ten Ordnung.
-// 'order' ist die Strafordnung 'p', 'size' ist die Anzahl der Datenpunkte.
-func makeDprimeDWH(order, size int) ([][]float64, error) {
+// dtdBandRaw erstellt eine symmetrische Banddiagonalmatrix D'*D aus der Differenzmatrix D der
+// p-ten Ordnung, im gepackten Format dPrimeD[d][i] = (D'D)_{i+d,i}. 'order' ist die
+// Strafordnung 'p', 'size' ist die Anzahl der Datenpunkte.
+func dtdBandRaw(order, size int) ([][]float64, error) {
 	if order < 1 || order > WH_MAX_ORDER {
 		return nil, fmt.Errorf("WhittakerSmoother: Ungültige Ordnung %d, muss zwischen 1 und %d liegen", order, WH_MAX_ORDER)
 	}
@@ -71,127 +85,89 @@ func makeDprimeDWH(order, size int) ([][]float64, error) {
 	return dPrimeD, nil
 }
 
-// timesLambdaPlusIdentWH modifiziert b zu I + lambda*b (wobei I die Identitätsmatrix ist).
-// b ist die D'*D Matrix.
-func timesLambdaPlusIdentWH(b [][]float64, lambda float64) {
-	if len(b) == 0 {
-		return
-	}
-	for i := 0; i < len(b[0]); i++ {
-		b[0][i] = 1.0 + b[0][i]*lambda
+// buildBandedDtD baut D'D direkt als *mat.SymBandDense auf (statt des früheren gepackten
+// [][]float64-Formats), damit die eigentliche Glättung gonum's Cholesky-Implementierung
+// überlassen werden kann.
+func buildBandedDtD(order, size int) (*mat.SymBandDense, error) {
+	raw, err := dtdBandRaw(order, size)
+	if err != nil {
+		return nil, err
 	}
-	for d := 1; d < len(b); d++ {
-		for i := 0; i < len(b[d]); i++ {
-			b[d][i] = b[d][i] * lambda
+
+	band := mat.NewSymBandDense(size, order, nil)
+	for d := 0; d <= order; d++ {
+		for i, v := range raw[d] {
+			band.SetSymBand(i, i+d, v)
 		}
 	}
+	return band, nil
 }
 
-// choleskyLWH führt die Cholesky-Zerlegung L*L' für eine symmetrische positiv-definite Banddiagonalmatrix durch.
-// Die Eingabe b (welche I + lambda*D'D ist) wird durch die untere Dreiecksmatrix L ersetzt.
-func choleskyLWH(b [][]float64) error {
-	if len(b) == 0 {
-		return errors.New("Cholesky: Matrix ist leer")
-	}
-	n := len(b[0])
-	dmax := len(b) - 1
-
-	for i := 0; i < n; i++ {
-		for jCol := math.Max(0, float64(i-dmax)); int(jCol) <= i; jCol++ {
-			j_java := int(jCol)
-			sum := 0.0
-			k_lower_bound := 0
-			if temp_k_lower := i - dmax; temp_k_lower > k_lower_bound {
-				k_lower_bound = temp_k_lower
-			}
-			if temp_k_lower := j_java - dmax; temp_k_lower > k_lower_bound {
-				k_lower_bound = temp_k_lower
-			}
-
-			for k := k_lower_bound; k < j_java; k++ {
-				dAik := i - k
-				dAjk := j_java - k
-				if dAik >= 0 && dAik < len(b) && k < len(b[dAik]) &&
-					dAjk >= 0 && dAjk < len(b) && k < len(b[dAjk]) {
-					sum += b[dAik][k] * b[dAjk][k]
-				} else {
-					return fmt.Errorf("Cholesky: Index außerhalb des Bereichs während der Summe bei i=%d, j=%d, k=%d", i, j_java, k)
-				}
-			}
-
-			if i == j_java {
-				diagVal := b[0][i] - sum
-				if diagVal <= 1e-12 {
-					return fmt.Errorf("Cholesky: Matrix nicht positiv definit bei i=%d, val=%f. Lambda prüfen oder Datenqualität", i, diagVal)
-				}
-				b[0][i] = math.Sqrt(diagVal)
-			} else {
-				bandIndex_Lij := i - j_java
-				if b[0][j_java] == 0 {
-					return fmt.Errorf("Cholesky: Division durch Null aufgrund von L_jj=0 bei j=%d", j_java)
+// scaleAndShiftBandWH formt eine D'D-Bandmatrix in-place zu W + lambda*D'D um, wobei
+// W = diag(weights) ist (bzw. die Identität, wenn weights nil ist).
+func scaleAndShiftBandWH(band *mat.SymBandDense, order, size int, lambda float64, weights []float64) {
+	for i := 0; i < size; i++ {
+		for d := 0; d <= order && i+d < size; d++ {
+			v := band.At(i, i+d) * lambda
+			if d == 0 {
+				diag := 1.0
+				if weights != nil {
+					diag = weights[i]
 				}
-				b[bandIndex_Lij][j_java] = (b[bandIndex_Lij][j_java] - sum) / b[0][j_java]
+				v += diag
 			}
+			band.SetSymBand(i, i+d, v)
 		}
 	}
-	return nil
 }
 
-// solveWH löst L*y = vec (Vorwärtssubstitution) und dann L'*x = y (Rückwärtssubstitution).
-// b ist die Cholesky-zerlegte Matrix L. vec sind die Eingabedaten.
-// Gibt x zurück, die geglätteten Daten.
-func solveWH(b [][]float64, vec []float64) ([]float64, error) {
-	if len(b) == 0 {
-		return nil, errors.New("Solve: Cholesky-Matrix L ist leer")
-	}
-	if len(b[0]) != len(vec) {
-		return nil, fmt.Errorf("Solve: Spalten der Matrix L (%d) stimmen nicht mit der Länge des Datenvektors (%d) überein", len(b[0]), len(vec))
+// factorizeWH baut W + lambda*D'D auf und liefert dessen gebänderte Cholesky-Zerlegung
+// (gonum/mat, mat.BandCholesky statt des dichten mat.Cholesky).
+func factorizeWH(order, size int, lambda float64, weights []float64) (*mat.BandCholesky, error) {
+	band, err := buildBandedDtD(order, size)
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Erstellen der D'D-Matrix: %w", err)
 	}
+	scaleAndShiftBandWH(band, order, size, lambda, weights)
 
-	n := len(vec)
-	out := make([]float64, n)
-	dmax := len(b) - 1
+	var chol mat.BandCholesky
+	if ok := chol.Factorize(band); !ok {
+		return nil, fmt.Errorf("Cholesky-Zerlegung fehlgeschlagen: Matrix nicht positiv definit (Ordnung %d, Lambda %g). Lambda prüfen oder Datenqualität", order, lambda)
+	}
+	return &chol, nil
+}
 
-	for i := 0; i < n; i++ {
-		sum := 0.0
-		j_lower_bound := 0
-		if temp_j_lower := i - dmax; temp_j_lower > 0 {
-			j_lower_bound = temp_j_lower
-		}
-		for j := j_lower_bound; j < i; j++ {
-			bandIndex_Lij := i - j
-			if bandIndex_Lij >= 0 && bandIndex_Lij < len(b) && j < len(b[bandIndex_Lij]) {
-				sum += b[bandIndex_Lij][j] * out[j]
-			} else {
-				return nil, fmt.Errorf("Solve (vorwärts): Index außerhalb des Bereichs für L_ij bei i=%d, j=%d", i, j)
-			}
-		}
-		if b[0][i] == 0 {
-			return nil, fmt.Errorf("Solve (vorwärts): Division durch Null aufgrund von L_ii=0 bei i=%d", i)
-		}
-		out[i] = (vec[i] - sum) / b[0][i]
+// solveCholWH löst (W + lambda*D'D) x = rhs über die gebänderte Cholesky-Zerlegung chol.
+func solveCholWH(chol *mat.BandCholesky, rhs []float64) ([]float64, error) {
+	b := mat.NewVecDense(len(rhs), append([]float64(nil), rhs...))
+	var dst mat.VecDense
+	if err := chol.SolveVecTo(&dst, b); err != nil {
+		return nil, fmt.Errorf("Solve fehlgeschlagen: %w", err)
 	}
+	return append([]float64(nil), dst.RawVector().Data...), nil
+}
 
-	for i := n - 1; i >= 0; i-- {
-		sum := 0.0
-		j_upper_bound := n
-		if temp_j_upper := i + dmax + 1; temp_j_upper < n {
-			j_upper_bound = temp_j_upper
-		}
-		for j := i + 1; j < j_upper_bound; j++ {
-			bandIndex_Lji := j - i
-			if bandIndex_Lji >= 0 && bandIndex_Lji < len(b) && i < len(b[bandIndex_Lji]) {
-				sum += b[bandIndex_Lji][i] * out[j]
-			} else {
-				return nil, fmt.Errorf("Solve (rückwärts): Index außerhalb des Bereichs für L_ji bei i=%d, j=%d", i, j)
-			}
-		}
-		if b[0][i] == 0 {
-			return nil, fmt.Errorf("Solve (rückwärts): Division durch Null aufgrund von L_ii=0 bei i=%d", i)
-		}
-		out[i] = (out[i] - sum) / b[0][i]
+// validateWeightsWH stellt sicher, dass weder am Anfang noch am Ende der Gewichte mehr als
+// 'order' aufeinanderfolgende Nullen stehen. Innerhalb dieser Grenze bleibt (W + lambda*D'D)
+// auch bei w_i=0 an inneren Punkten positiv definit; zu lange Nullläufe an einem Rand machen
+// die Randwerte dagegen unterbestimmt.
+func validateWeightsWH(weights []float64, order int) error {
+	n := len(weights)
+	leading := 0
+	for leading < n && weights[leading] == 0 {
+		leading++
 	}
-	return out, nil
+	if leading > order {
+		return fmt.Errorf("WhittakerSmoother (gewichtet): %d aufeinanderfolgende Nullgewichte am Anfang überschreiten die Ordnung %d", leading, order)
+	}
+	trailing := 0
+	for trailing < n && weights[n-1-trailing] == 0 {
+		trailing++
+	}
+	if trailing > order {
+		return fmt.Errorf("WhittakerSmoother (gewichtet): %d aufeinanderfolgende Nullgewichte am Ende überschreiten die Ordnung %d", trailing, order)
+	}
+	return nil
 }
 
 // NewWhittakerHendersonSmoother erstellt eine Smoother-Instanz.
@@ -202,16 +178,45 @@ func NewWhittakerHendersonSmoother(length, order int, lambda float64) (*Whittake
 	if length <= order || length < 2 {
 		return nil, fmt.Errorf("Datenlänge %d zu kurz für Ordnung %d oder minimale Verarbeitung", length, order)
 	}
-	matrixA, err := makeDprimeDWH(order, length)
+	chol, err := factorizeWH(order, length, lambda, nil)
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Erstellen der D'D-Matrix: %w", err)
+		return nil, err
+	}
+	return &WhittakerHendersonSmoother{chol: chol, length: length, order: order, lambda: lambda}, nil
+}
+
+// NewWeightedWhittakerHendersonSmoother erstellt eine Smoother-Instanz für das gewichtete
+// WH-Problem (W + lambda*D'D) x = W*y, wobei w_i=0 einen fehlenden Messpunkt markiert.
+// length, order, lambda: wie bei NewWhittakerHendersonSmoother.
+// weights: ein Gewicht pro Datenpunkt, 0 für fehlende Samples.
+//
+// Dies ermöglicht sowohl das Auffüllen von Lücken (Dropouts in Federweg-Messreihen) als auch
+// ausreißerrobustes Glätten über eine IRLS-Schleife: Huber-Gewichte
+//
+//	w_i = 1,                          |r_i| <= k
+//	w_i = k / |r_i|,                  |r_i| >  k
+//
+// werden aus den Residuen r = y - ŷ(vorherige Iteration) berechnet und der Smoother mit
+// SmoothWeighted erneut auf die aktualisierten Gewichte angewendet, bis die Residuen
+// konvergieren.
+func NewWeightedWhittakerHendersonSmoother(length, order int, lambda float64, weights []float64) (*WhittakerHendersonSmoother, error) {
+	if length <= order || length < 2 {
+		return nil, fmt.Errorf("Datenlänge %d zu kurz für Ordnung %d oder minimale Verarbeitung", length, order)
+	}
+	if len(weights) != length {
+		return nil, fmt.Errorf("Länge der Gewichte (%d) stimmt nicht mit der Datenlänge (%d) überein", len(weights), length)
 	}
-	timesLambdaPlusIdentWH(matrixA, lambda)
-	err = choleskyLWH(matrixA)
+	if err := validateWeightsWH(weights, order); err != nil {
+		return nil, err
+	}
+
+	chol, err := factorizeWH(order, length, lambda, weights)
 	if err != nil {
-		return nil, fmt.Errorf("Cholesky-Zerlegung fehlgeschlagen: %w", err)
+		return nil, err
 	}
-	return &WhittakerHendersonSmoother{matrix: matrixA, length: length}, nil
+
+	weightsCopy := append([]float64(nil), weights...)
+	return &WhittakerHendersonSmoother{chol: chol, length: length, order: order, lambda: lambda, weights: weightsCopy}, nil
 }
 
 // Smooth wendet die Whittaker-Henderson-Glättung auf die Daten an.
@@ -219,8 +224,309 @@ func (whs *WhittakerHendersonSmoother) Smooth(data []float64) ([]float64, error)
 	if len(data) != whs.length {
 		return nil, fmt.Errorf("Datenlänge %d stimmt nicht mit der vorkonfigurierten Länge des Smoothers %d überein", len(data), whs.length)
 	}
-	if whs.matrix == nil {
-		return nil, errors.New("Smoother-Matrix nicht initialisiert")
+	if whs.chol == nil {
+		return nil, errors.New("Smoother nicht initialisiert")
+	}
+	if whs.weights == nil {
+		return solveCholWH(whs.chol, data)
+	}
+	rhs := make([]float64, len(data))
+	for i, v := range data {
+		rhs[i] = whs.weights[i] * v
+	}
+	return solveCholWH(whs.chol, rhs)
+}
+
+// SmoothBatch glättet mehrere Kanäle (z. B. Travel von Front und Heck) in einer einzigen
+// Cholesky-Zerlegung, über mat.BandCholesky.SolveTo mit mehrspaltiger rechter Seite.
+func (whs *WhittakerHendersonSmoother) SmoothBatch(columns [][]float64) ([][]float64, error) {
+	if whs.chol == nil {
+		return nil, errors.New("Smoother nicht initialisiert")
+	}
+	if len(columns) == 0 {
+		return [][]float64{}, nil
+	}
+
+	rhs := mat.NewDense(whs.length, len(columns), nil)
+	for c, col := range columns {
+		if len(col) != whs.length {
+			return nil, fmt.Errorf("Datenlänge %d von Kanal %d stimmt nicht mit der vorkonfigurierten Länge des Smoothers %d überein", len(col), c, whs.length)
+		}
+		for i, v := range col {
+			if whs.weights != nil {
+				v *= whs.weights[i]
+			}
+			rhs.Set(i, c, v)
+		}
+	}
+
+	var dst mat.Dense
+	if err := whs.chol.SolveTo(&dst, rhs); err != nil {
+		return nil, fmt.Errorf("Solve (batch) fehlgeschlagen: %w", err)
+	}
+
+	out := make([][]float64, len(columns))
+	for c := range columns {
+		col := make([]float64, whs.length)
+		for i := 0; i < whs.length; i++ {
+			col[i] = dst.At(i, c)
+		}
+		out[c] = col
+	}
+	return out, nil
+}
+
+// SmoothWeighted glättet data mit pro Aufruf wechselnden Gewichten. Anders als Smooth wird
+// hierbei die Banddiagonalmatrix für jeden Aufruf neu aufgebaut und Cholesky-zerlegt, da sich
+// die Gewichte i. d. R. zwischen Aufrufen ändern (z. B. in einer IRLS-Schleife mit
+// Huber-Gewichten, siehe NewWeightedWhittakerHendersonSmoother).
+func (whs *WhittakerHendersonSmoother) SmoothWeighted(data, weights []float64) ([]float64, error) {
+	if len(data) != whs.length {
+		return nil, fmt.Errorf("Datenlänge %d stimmt nicht mit der vorkonfigurierten Länge des Smoothers %d überein", len(data), whs.length)
+	}
+	if len(weights) != whs.length {
+		return nil, fmt.Errorf("Länge der Gewichte (%d) stimmt nicht mit der Datenlänge (%d) überein", len(weights), whs.length)
+	}
+	if err := validateWeightsWH(weights, whs.order); err != nil {
+		return nil, err
+	}
+
+	chol, err := factorizeWH(whs.order, whs.length, whs.lambda, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	rhs := make([]float64, len(data))
+	for i, v := range data {
+		rhs[i] = weights[i] * v
 	}
-	return solveWH(whs.matrix, data)
-}
\ No newline at end of file
+	return solveCholWH(chol, rhs)
+}
+
+// factorizeBandForSelectedInverse baut W + lambda*D'D genau wie factorizeWH auf, zerlegt es aber
+// über lapack64.Pbtrf direkt (statt über den mat.BandCholesky-Wrapper) und gibt den gebänderten
+// oberen Dreiecksfaktor U roh zurück (A = U'*U). SelectLambdaGCV/SelectLambdaLcurve brauchen
+// lesenden Zugriff auf die Bandeinträge des Faktors selbst für die Spurschätzung
+// (selectedInverseDiagFromFactor); mat.BandCholesky stellt dafür keine öffentliche Methode zur
+// Verfügung - At/TBand rekonstruieren Einträge der ursprünglichen Matrix, nicht des Faktors. Die
+// Faktorisierung selbst läuft über dasselbe LAPACK dpbtrf, das mat.BandCholesky.Factorize intern
+// verwendet; es wird also nach wie vor keine eigene Cholesky-Implementierung gepflegt.
+func factorizeBandForSelectedInverse(order, size int, lambda float64, weights []float64) (blas64.TriangularBand, bool, error) {
+	band, err := buildBandedDtD(order, size)
+	if err != nil {
+		return blas64.TriangularBand{}, false, fmt.Errorf("Fehler beim Erstellen der D'D-Matrix: %w", err)
+	}
+	scaleAndShiftBandWH(band, order, size, lambda, weights)
+
+	t, ok := lapack64.Pbtrf(band.RawSymBand())
+	return t, ok, nil
+}
+
+// solveTriBandWH löst A*x = rhs über den gebänderten oberen Cholesky-Faktor t (A = U'*U, von
+// factorizeBandForSelectedInverse/lapack64.Pbtrf) mittels lapack64.Pbtrs.
+func solveTriBandWH(t blas64.TriangularBand, rhs []float64) []float64 {
+	b := blas64.General{Rows: len(rhs), Cols: 1, Stride: 1, Data: append([]float64(nil), rhs...)}
+	lapack64.Pbtrs(t, b)
+	return b.Data
+}
+
+// selectedInverseDiagFromFactor berechnet die Hauptdiagonale von (U'*U)^-1 für den gebänderten
+// oberen Cholesky-Faktor U aus factorizeBandForSelectedInverse, ohne die volle Inverse zu bilden.
+// Es wird die Takahashi-Rekursion für Bandmatrizen verwendet: rückwärts ab dem letzten Index wird
+// z_{i+k,i} aus bereits bekannten Einträgen von Z innerhalb der Bandbreite aufgebaut, und
+// anschließend z_ii = 1/L_ii^2 - sum_{k=1..p} (L_{i+k,i}/L_ii) * z_{i+k,i}, wobei L = U' (die
+// unteren Bandeinträge L_{i+k,i} sind identisch zu U_{i,i+k}, nur transponiert im Speicher).
+func selectedInverseDiagFromFactor(t blas64.TriangularBand) []float64 {
+	n, dmax := t.N, t.K
+
+	// L_{row,col} = U_{col,row} für row >= col, row-col <= dmax; U ist zeilenweise gepackt mit
+	// U_{i,j} bei t.Data[i*t.Stride+(j-i)].
+	L := func(row, col int) float64 {
+		if row < col || row-col > dmax {
+			return 0
+		}
+		return t.Data[col*t.Stride+(row-col)]
+	}
+
+	// Z im selben gebänderten Layout wie bisher: Z[d][i] = (U'*U)^-1 an Position (i+d, i).
+	Z := make([][]float64, dmax+1)
+	for d := range Z {
+		Z[d] = make([]float64, n)
+	}
+	zEntry := func(row, col int) float64 {
+		if row < col {
+			row, col = col, row
+		}
+		d := row - col
+		if d > dmax {
+			return 0
+		}
+		return Z[d][col]
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		Lii := L(i, i)
+		for k := 1; k <= dmax && i+k < n; k++ {
+			var sum float64
+			for j := 1; j <= dmax && i+j < n; j++ {
+				sum += L(i+j, i) * zEntry(i+k, i+j)
+			}
+			Z[k][i] = -sum / Lii
+		}
+
+		var diagSum float64
+		for k := 1; k <= dmax && i+k < n; k++ {
+			diagSum += (L(i+k, i) / Lii) * Z[k][i]
+		}
+		Z[0][i] = 1.0/(Lii*Lii) - diagSum
+	}
+
+	return Z[0]
+}
+
+// applyDiffWH wendet den Differenzenoperator D der gegebenen Ordnung auf data an und liefert
+// ein Ergebnis der Länge len(data)-order (dieselben Koeffizienten wie dtdBandRaw, aber ohne die
+// D'D-Faltung).
+func applyDiffWH(data []float64, order int) ([]float64, error) {
+	if order < 1 || order > WH_MAX_ORDER {
+		return nil, fmt.Errorf("WhittakerSmoother: Ungültige Ordnung %d, muss zwischen 1 und %d liegen", order, WH_MAX_ORDER)
+	}
+	coeffs := WH_DIFF_COEFF[order-1]
+	if len(data) <= order {
+		return nil, fmt.Errorf("WhittakerSmoother: Datenlänge %d zu kurz für Ordnung %d", len(data), order)
+	}
+	out := make([]float64, len(data)-order)
+	for i := range out {
+		var sum float64
+		for j, c := range coeffs {
+			sum += c * data[i+j]
+		}
+		out[i] = sum
+	}
+	return out, nil
+}
+
+// SelectLambdaGCV wählt aus lambdaGrid den Glättungsparameter, der den Generalized-Cross-
+// Validation-Score
+//
+//	GCV(lambda) = n * ||y - yhat(lambda)||^2 / (n - tr(H(lambda)))^2
+//
+// minimiert, wobei H(lambda) = (I + lambda*D'D)^-1 die Hat-Matrix ist. Die Spur wird über
+// selectedInverseDiagFromFactor aus dem gebänderten Cholesky-Faktor bestimmt, ohne H explizit zu
+// bilden (mat.Cholesky.InverseTo würde die volle n×n-Inverse materialisieren, was für die
+// hier relevanten Aufnahmelängen von 10k–200k Samples nicht praktikabel ist).
+func SelectLambdaGCV(data []float64, order int, lambdaGrid []float64) (float64, error) {
+	n := len(data)
+	if n <= order || n < 2 {
+		return 0, fmt.Errorf("Datenlänge %d zu kurz für Ordnung %d oder minimale Verarbeitung", n, order)
+	}
+	if len(lambdaGrid) == 0 {
+		return 0, errors.New("WhittakerSmoother: lambdaGrid ist leer")
+	}
+
+	bestLambda := lambdaGrid[0]
+	bestScore := math.Inf(1)
+	for _, lambda := range lambdaGrid {
+		t, ok, err := factorizeBandForSelectedInverse(order, n, lambda, nil)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		yhat := solveTriBandWH(t, data)
+
+		var trace float64
+		for _, z := range selectedInverseDiagFromFactor(t) {
+			trace += z
+		}
+		denom := float64(n) - trace
+		if denom == 0 {
+			continue
+		}
+
+		var rss float64
+		for i, v := range data {
+			d := v - yhat[i]
+			rss += d * d
+		}
+
+		score := float64(n) * rss / (denom * denom)
+		if score < bestScore {
+			bestScore = score
+			bestLambda = lambda
+		}
+	}
+
+	if math.IsInf(bestScore, 1) {
+		return 0, errors.New("WhittakerSmoother: GCV-Auswahl hat für kein Lambda im Grid konvergiert")
+	}
+	return bestLambda, nil
+}
+
+// SelectLambdaLcurve wählt aus lambdaGrid den Glättungsparameter am Punkt maximaler Krümmung
+// der L-Kurve, d. h. von log(||y-yhat(lambda)||^2) gegen log(||D*yhat(lambda)||^2). lambdaGrid
+// muss aufsteigend sortiert sein. Die Krümmung wird über zentrale Differenzen entlang des
+// Gitters approximiert; die Randpunkte werden dabei übersprungen, da sie keine Nachbarn auf
+// beiden Seiten haben.
+func SelectLambdaLcurve(data []float64, order int, lambdaGrid []float64) (float64, error) {
+	n := len(data)
+	if n <= order || n < 2 {
+		return 0, fmt.Errorf("Datenlänge %d zu kurz für Ordnung %d oder minimale Verarbeitung", n, order)
+	}
+	if len(lambdaGrid) < 3 {
+		return 0, errors.New("WhittakerSmoother: L-Kurven-Auswahl benötigt mindestens 3 Lambda-Werte")
+	}
+
+	logRSS := make([]float64, len(lambdaGrid))
+	logRoughness := make([]float64, len(lambdaGrid))
+	for i, lambda := range lambdaGrid {
+		t, ok, err := factorizeBandForSelectedInverse(order, n, lambda, nil)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, fmt.Errorf("Cholesky-Zerlegung für Lambda=%g fehlgeschlagen: Matrix nicht positiv definit", lambda)
+		}
+		yhat := solveTriBandWH(t, data)
+
+		var rss float64
+		for i, v := range data {
+			d := v - yhat[i]
+			rss += d * d
+		}
+
+		diff, err := applyDiffWH(yhat, order)
+		if err != nil {
+			return 0, err
+		}
+		var roughness float64
+		for _, d := range diff {
+			roughness += d * d
+		}
+
+		logRSS[i] = math.Log(math.Max(rss, 1e-300))
+		logRoughness[i] = math.Log(math.Max(roughness, 1e-300))
+	}
+
+	bestLambda := lambdaGrid[0]
+	bestCurvature := math.Inf(-1)
+	for i := 1; i < len(lambdaGrid)-1; i++ {
+		xPrime := (logRSS[i+1] - logRSS[i-1]) / 2
+		yPrime := (logRoughness[i+1] - logRoughness[i-1]) / 2
+		xDoublePrime := logRSS[i+1] - 2*logRSS[i] + logRSS[i-1]
+		yDoublePrime := logRoughness[i+1] - 2*logRoughness[i] + logRoughness[i-1]
+
+		denom := math.Pow(xPrime*xPrime+yPrime*yPrime, 1.5)
+		if denom == 0 {
+			continue
+		}
+		curvature := math.Abs(xPrime*yDoublePrime-yPrime*xDoublePrime) / denom
+		if curvature > bestCurvature {
+			bestCurvature = curvature
+			bestLambda = lambdaGrid[i]
+		}
+	}
+
+	return bestLambda, nil
+}
@@ -0,0 +1,247 @@
+package psst
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// syntheticTravel builds a deterministic, noisy ramp-like series for benchmarking, long enough
+// that smoother cost is dominated by factorization rather than allocation noise.
+func syntheticTravel(n int) []float64 {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 50*math.Sin(float64(i)/97.0) + float64(i%7)
+	}
+	return data
+}
+
+// TestWeightedSmootherInterpolatesZeroWeightedGaps checks that SmoothWeighted/
+// NewWeightedWhittakerHendersonSmoother actually fill in zero-weighted samples with plausible
+// values, rather than e.g. leaving them at the raw (garbage) input or collapsing the whole
+// solve. A handful of interior points, including a run of three consecutive samples, are zeroed
+// out; their solved values must land close to the underlying trend
+// the surrounding, fully-weighted samples describe.
+func TestWeightedSmootherInterpolatesZeroWeightedGaps(t *testing.T) {
+	const n = 200
+	trend := make([]float64, n)
+	data := make([]float64, n)
+	weights := make([]float64, n)
+	for i := range data {
+		trend[i] = 0.02 * float64(i)
+		data[i] = trend[i]
+		weights[i] = 1
+	}
+
+	gaps := []int{20, 21, 100, 150, 151, 152}
+	for _, i := range gaps {
+		weights[i] = 0
+		data[i] = 1e6 // garbage: a correct solve must ignore this entirely
+	}
+
+	whs, err := NewWeightedWhittakerHendersonSmoother(n, WH_ORDER, WH_LAMBDA, weights)
+	if err != nil {
+		t.Fatalf("NewWeightedWhittakerHendersonSmoother: %v", err)
+	}
+	smoothed, err := whs.SmoothWeighted(data, weights)
+	if err != nil {
+		t.Fatalf("SmoothWeighted: %v", err)
+	}
+
+	for _, i := range gaps {
+		if d := math.Abs(smoothed[i] - trend[i]); d > 0.05 {
+			t.Errorf("gap at i=%d: smoothed=%.4f, want close to trend=%.4f (diff %.4f)", i, smoothed[i], trend[i], d)
+		}
+	}
+}
+
+// TestValidateWeightsWHRejectsLongZeroRuns checks that validateWeightsWH (exercised via
+// NewWeightedWhittakerHendersonSmoother) rejects a leading or trailing run of zero weights
+// longer than the penalty order, since such a run leaves the affected edge underdetermined
+// (see validateWeightsWH's doc comment).
+func TestValidateWeightsWHRejectsLongZeroRuns(t *testing.T) {
+	const n = 50
+
+	leading := make([]float64, n)
+	for i := WH_ORDER + 1; i < n; i++ {
+		leading[i] = 1
+	}
+	if _, err := NewWeightedWhittakerHendersonSmoother(n, WH_ORDER, WH_LAMBDA, leading); err == nil {
+		t.Error("expected an error for a leading zero-weight run longer than WH_ORDER, got nil")
+	}
+
+	trailing := make([]float64, n)
+	for i := 0; i < n-(WH_ORDER+1); i++ {
+		trailing[i] = 1
+	}
+	if _, err := NewWeightedWhittakerHendersonSmoother(n, WH_ORDER, WH_LAMBDA, trailing); err == nil {
+		t.Error("expected an error for a trailing zero-weight run longer than WH_ORDER, got nil")
+	}
+}
+
+// noisyWave builds a deterministic, curved trend (so that both underfitting the jitter and
+// oversmoothing the curvature cost something) overlaid with a small, repeating jitter pattern -
+// noisy enough that an unsmoothed fit overfits it, but small enough that a well-chosen lambda
+// should mostly average it out without flattening the underlying wave.
+func noisyWave(n int) []float64 {
+	jitter := []float64{0.6, -0.4, 0.3, -0.7, 0.5, -0.2}
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 20*math.Sin(float64(i)/25.0) + jitter[i%len(jitter)]
+	}
+	return data
+}
+
+// denseSelectedInverseDiag computes the reference diagonal of (W + lambda*D'D)^-1 by building
+// the matrix densely and inverting it outright, for comparison against
+// selectedInverseDiagFromFactor's banded Takahashi recursion.
+func denseSelectedInverseDiag(t *testing.T, order, n int, lambda float64) []float64 {
+	t.Helper()
+	band, err := buildBandedDtD(order, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaleAndShiftBandWH(band, order, n, lambda, nil)
+
+	dense := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			dense.SetSym(i, j, band.At(i, j))
+		}
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(dense); err != nil {
+		t.Fatal(err)
+	}
+	diag := make([]float64, n)
+	for i := range diag {
+		diag[i] = inv.At(i, i)
+	}
+	return diag
+}
+
+// TestSelectedInverseDiagFromFactorMatchesDenseInverse checks selectedInverseDiagFromFactor's
+// banded Takahashi recursion, which SelectLambdaGCV relies on for its GCV trace, against a
+// dense-inverse ground truth across a few sizes, orders and lambdas.
+func TestSelectedInverseDiagFromFactorMatchesDenseInverse(t *testing.T) {
+	for _, n := range []int{15, 40} {
+		for _, order := range []int{1, 2, 3} {
+			for _, lambda := range []float64{0.5, 10, 250} {
+				tri, ok, err := factorizeBandForSelectedInverse(order, n, lambda, nil)
+				if err != nil {
+					t.Fatalf("n=%d order=%d lambda=%g: %v", n, order, lambda, err)
+				}
+				if !ok {
+					t.Fatalf("n=%d order=%d lambda=%g: factorization failed", n, order, lambda)
+				}
+				got := selectedInverseDiagFromFactor(tri)
+				want := denseSelectedInverseDiag(t, order, n, lambda)
+
+				for i := range got {
+					if d := math.Abs(got[i] - want[i]); d > 1e-6 {
+						t.Errorf("n=%d order=%d lambda=%g i=%d: got %.9f, want %.9f", n, order, lambda, i, got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestSelectLambdaGCVPicksInteriorLambda checks that SelectLambdaGCV does not just fall back to
+// one of the grid's extremes on noisy data: the smallest lambda overfits the jitter (inflating
+// the GCV score via a near-saturated trace), the largest oversmooths the wave's actual curvature,
+// so a sane pick should land strictly inside the grid.
+func TestSelectLambdaGCVPicksInteriorLambda(t *testing.T) {
+	data := noisyWave(300)
+	grid := []float64{0.01, 0.1, 1, 10, 100, 1000, 10000}
+
+	got, err := SelectLambdaGCV(data, WH_ORDER, grid)
+	if err != nil {
+		t.Fatalf("SelectLambdaGCV: %v", err)
+	}
+	if got == grid[0] || got == grid[len(grid)-1] {
+		t.Errorf("SelectLambdaGCV picked a grid extreme (%g) for noisy data, want an interior value", got)
+	}
+}
+
+// TestSelectLambdaLcurvePicksInteriorLambda mirrors
+// TestSelectLambdaGCVPicksInteriorLambda for the L-curve selector.
+func TestSelectLambdaLcurvePicksInteriorLambda(t *testing.T) {
+	data := noisyWave(300)
+	grid := []float64{0.01, 0.1, 1, 10, 100, 1000, 10000}
+
+	got, err := SelectLambdaLcurve(data, WH_ORDER, grid)
+	if err != nil {
+		t.Fatalf("SelectLambdaLcurve: %v", err)
+	}
+	if got == grid[0] || got == grid[len(grid)-1] {
+		t.Errorf("SelectLambdaLcurve picked a grid extreme (%g) for noisy data, want an interior value", got)
+	}
+}
+
+// BenchmarkWhittakerHendersonSmoother exercises NewWhittakerHendersonSmoother+Smooth across the
+// 10k-200k sample range ProcessRecording actually sees, to guard against factorizeWH regressing
+// back onto a dense (non-banded) Cholesky path, whose factorization time scales cubically with n
+// instead of linearly. Run with -benchtime=1x and -timeout=0 to cover the full 200k case; the
+// condition-number estimate that mat.BandCholesky.Factorize computes internally (via
+// lapack64.Pbcon/Dlatbs) is itself O(n^2), so even the banded path is far from instant at the top
+// of the range - still many orders of magnitude better than the dense path it replaces.
+func BenchmarkWhittakerHendersonSmoother(b *testing.B) {
+	for _, n := range []int{10000, 50000, 100000, 200000} {
+		data := syntheticTravel(n)
+		b.Run(sizeLabel(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				whs, err := NewWhittakerHendersonSmoother(n, WH_ORDER, WH_LAMBDA)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := whs.Smooth(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWhittakerHendersonFactorizeDenseVsBanded compares factorizeWH's banded
+// mat.BandCholesky path against a dense mat.Cholesky factorization of the same W + lambda*D'D
+// matrix, at sizes small enough for the dense path to finish. The dense path densifies the band
+// into a full n×n matrix before running LAPACK dpotrf, so its cost grows cubically with n while
+// the banded path's grows linearly; this benchmark is what would have caught factorizeWH
+// accidentally using mat.Cholesky instead of mat.BandCholesky.
+func BenchmarkWhittakerHendersonFactorizeDenseVsBanded(b *testing.B) {
+	for _, n := range []int{500, 1000, 2000, 4000} {
+		band, err := buildBandedDtD(WH_ORDER, n)
+		if err != nil {
+			b.Fatal(err)
+		}
+		scaleAndShiftBandWH(band, WH_ORDER, n, WH_LAMBDA, nil)
+
+		b.Run("banded/"+sizeLabel(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var chol mat.BandCholesky
+				if ok := chol.Factorize(band); !ok {
+					b.Fatal("banded factorization failed")
+				}
+			}
+		})
+		b.Run("dense/"+sizeLabel(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var chol mat.Cholesky
+				if ok := chol.Factorize(band); !ok {
+					b.Fatal("dense factorization failed")
+				}
+			}
+		})
+	}
+}
+
+func sizeLabel(n int) string {
+	if n >= 1000 {
+		return strconv.Itoa(n/1000) + "k"
+	}
+	return strconv.Itoa(n)
+}